@@ -0,0 +1,48 @@
+package pretty
+
+import "unicode/utf8"
+
+// Canonical returns the json in the canonical form that's described by the
+// JSON Canonicalization Scheme, https://tools.ietf.org/html/rfc8785. The
+// output is compacted, with the object keys sorted by their UTF-16 code
+// units, the numbers in their ECMAScript form, and the strings using the
+// minimal escapes. It returns a *SyntaxError when the input is not valid
+// I-JSON, such as when an object has a duplicate key.
+func Canonical(json []byte) ([]byte, error) {
+	v := validator{ijson: true, surrogates: true}
+	v.DisallowDuplicateKeys = true
+	if err := v.validate(json); err != nil {
+		return nil, err
+	}
+	opts := Options{Width: -1, SortKeys: true, KeyLess: lessUTF16,
+		Escapes: EscapeMinimal, Numbers: NumbersShortest}
+	buf := make([]byte, 0, len(json))
+	buf, _, _, _ = appendPrettyAny(buf, json, 0, false, &opts, &state{}, 0, 0, -1)
+	return buf, nil
+}
+
+// lessUTF16 compares the strings by their UTF-16 code units.
+func lessUTF16(a, b []byte) bool {
+	for len(a) > 0 && len(b) > 0 {
+		r1, n1 := utf8.DecodeRune(a)
+		r2, n2 := utf8.DecodeRune(b)
+		if r1 != r2 {
+			if r1 >= 0x10000 && r2 >= 0x10000 {
+				return r1 < r2
+			}
+			// a supplementary character is compared by its high surrogate,
+			// which is less than some of the BMP characters
+			return utf16First(r1) < utf16First(r2)
+		}
+		a, b = a[n1:], b[n2:]
+	}
+	return len(a) < len(b)
+}
+
+// utf16First returns the first UTF-16 code unit of the rune.
+func utf16First(r rune) rune {
+	if r < 0x10000 {
+		return r
+	}
+	return 0xD800 + (r-0x10000)>>10
+}
@@ -0,0 +1,112 @@
+package pretty
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	// https://tools.ietf.org/html/rfc8785#section-3.2.2
+	json := `{
+  "numbers": [333333333.33333329, 1E30, 4.50,
+              2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+	res, err := Canonical([]byte(json))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"literals":[null,true,false],"numbers":[333333333.3333333,` +
+		`1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	assertEqual(t, expect, string(res))
+
+	// https://tools.ietf.org/html/rfc8785#section-3.2.3
+	json = `{
+  "\u20ac": "Euro Sign",
+  "\r": "Carriage Return",
+  "\ufb33": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "\ud83d\ude00": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "\u00f6": "Latin Small Letter O With Diaeresis"
+}`
+	res, err = Canonical([]byte(json))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\"," +
+		"\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\"," +
+		"\"😀\":\"Emoji: Grinning Face\"," +
+		"\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+	assertEqual(t, expect, string(res))
+}
+
+func TestCanonicalNumbers(t *testing.T) {
+	// https://tools.ietf.org/html/rfc8785#appendix-B
+	tests := []struct {
+		bits   uint64
+		expect string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+	for _, tt := range tests {
+		f := math.Float64frombits(tt.bits)
+		res, err := Canonical([]byte(strconv.FormatFloat(f, 'g', -1, 64)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, tt.expect, string(res))
+	}
+}
+
+func TestCanonicalErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		err  string
+	}{
+		{`{"a":1,"b":2,"a":3}`, `pretty: duplicate key "a" at line 1, ` +
+			`column 14, expected unique key`},
+		{`[1e400]`, `pretty: number 1e400 out of range at line 1, ` +
+			`column 2, expected number in the range of a double`},
+		{`["a\ud800"]`, `pretty: lone surrogate \ud800 at line 1, ` +
+			`column 4, expected surrogate pair`},
+		{`["\ude00\ud83d"]`, `pretty: lone surrogate \ude00 at line 1, ` +
+			`column 3, expected surrogate pair`},
+		{`["\ud83d\u0041"]`, `pretty: lone surrogate \ud83d at line 1, ` +
+			`column 3, expected surrogate pair`},
+		{`{"a":NaN}`, `pretty: invalid character 'N' at line 1, ` +
+			`column 6, expected value`},
+	}
+	for _, tt := range tests {
+		_, err := Canonical([]byte(tt.json))
+		if err == nil {
+			t.Fatalf("%s: expected an error", tt.json)
+		}
+		assertEqual(t, tt.err, err.Error())
+	}
+}
@@ -0,0 +1,267 @@
+package pretty
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorLevel is the number of colors that a terminal supports
+type ColorLevel int
+
+const (
+	// ColorNone is for writers that should not get color, such as files
+	ColorNone ColorLevel = iota
+	// Color16 is for the basic ANSI colors, which TerminalStyle uses
+	Color16
+	// Color256 is for the 256 colors of xterm
+	Color256
+	// ColorTrue is for 24-bit color
+	ColorTrue
+)
+
+// ColorDetector decides how much color to write to a writer, using the
+// NO_COLOR, FORCE_COLOR, COLORTERM, and TERM environment variables, and
+// whether the writer is a terminal. The zero value uses the environment of
+// the process.
+type ColorDetector struct {
+	// Getenv returns the value of an environment variable, and whether it's
+	// set
+	// Default is nil, which is os.LookupEnv
+	Getenv func(key string) (string, bool)
+	// IsTerminal returns true if the file descriptor is a terminal. It's
+	// only called for writers that have an Fd method, such as *os.File.
+	// Default is nil, which asks the operating system, and is false on the
+	// systems where it can't tell
+	IsTerminal func(fd uintptr) bool
+}
+
+// DetectColor returns the color level of the writer, using the environment
+// of the process.
+func DetectColor(w io.Writer) ColorLevel {
+	return (&ColorDetector{}).Level(w)
+}
+
+// DetectStyle returns TerminalStyle downgraded to the color level of the
+// writer. When the writer should not get color it's a style without any
+// colors, which Color writes the json with as it is.
+func DetectStyle(w io.Writer) *Style {
+	return (&ColorDetector{}).Style(w, TerminalStyle)
+}
+
+// ColorFor colorizes the json with TerminalStyle when the writer should get
+// color, or otherwise returns the json as it is.
+func ColorFor(w io.Writer, src []byte) []byte {
+	if DetectColor(w) == ColorNone {
+		return src
+	}
+	return Color(src, DetectStyle(w))
+}
+
+// Level returns the color level of the writer.
+//
+// A NO_COLOR that is not empty turns color off, and it comes before
+// FORCE_COLOR, as https://no-color.org asks. FORCE_COLOR, when set, turns on
+// color even when the writer is not a terminal. A value of 0 or false turns
+// color off, and 1, 2, or 3 is the least level of 16, 256, or 24-bit color.
+// Otherwise a writer that is not a terminal, or a TERM of dumb, turns color
+// off. The level is then ColorTrue for a COLORTERM of truecolor or 24bit,
+// Color256 for a TERM that has 256color, or Color16.
+func (d *ColorDetector) Level(w io.Writer) ColorLevel {
+	getenv := d.Getenv
+	if getenv == nil {
+		getenv = os.LookupEnv
+	}
+	if noColor, _ := getenv("NO_COLOR"); noColor != "" {
+		return ColorNone
+	}
+	level := ColorNone
+	if force, ok := getenv("FORCE_COLOR"); ok {
+		switch strings.ToLower(force) {
+		case "0", "false":
+			return ColorNone
+		case "", "1", "true":
+			level = Color16
+		case "2":
+			level = Color256
+		case "3":
+			level = ColorTrue
+		default:
+			if n, err := strconv.Atoi(force); err == nil && n > 3 {
+				level = ColorTrue
+			} else {
+				level = Color16
+			}
+		}
+	} else if !d.isTerminal(w) {
+		return ColorNone
+	}
+	term, _ := getenv("TERM")
+	if term == "dumb" && level == ColorNone {
+		return ColorNone
+	}
+	detected := Color16
+	colorterm, _ := getenv("COLORTERM")
+	switch {
+	case colorterm == "truecolor" || colorterm == "24bit" ||
+		strings.HasSuffix(term, "-direct"):
+		detected = ColorTrue
+	case strings.Contains(term, "256color"):
+		detected = Color256
+	}
+	if detected > level {
+		level = detected
+	}
+	return level
+}
+
+// Style returns the style downgraded to the color level of the writer, or a
+// style without any colors when the writer should not get color.
+func (d *ColorDetector) Style(w io.Writer, style *Style) *Style {
+	level := d.Level(w)
+	if level == ColorNone {
+		return &Style{}
+	}
+	return style.Downgrade(level)
+}
+
+func (d *ColorDetector) isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return false
+	}
+	if d.IsTerminal != nil {
+		return d.IsTerminal(f.Fd())
+	}
+	return isatty(f.Fd())
+}
+
+// Downgrade returns a copy of the style with the 24-bit and 256 colors
+// changed to the nearest colors of the level. With ColorNone all of the
+// escape sequences are removed.
+func (s *Style) Downgrade(level ColorLevel) *Style {
+	ds := *s
+	for _, c := range []*[2]string{&ds.Key, &ds.String, &ds.Number, &ds.True,
+		&ds.False, &ds.Null, &ds.Escape, &ds.Brackets, &ds.Match} {
+		c[0] = downgradeSGR(c[0], level)
+		c[1] = downgradeSGR(c[1], level)
+	}
+	if s.DepthBrackets != nil {
+		ds.DepthBrackets = make([][2]string, len(s.DepthBrackets))
+		for i, c := range s.DepthBrackets {
+			ds.DepthBrackets[i] = [2]string{downgradeSGR(c[0], level),
+				downgradeSGR(c[1], level)}
+		}
+	}
+	return &ds
+}
+
+// downgradeSGR changes the colors of the escape sequences in s, which have
+// the form "\x1B[...m", to the level.
+func downgradeSGR(s string, level ColorLevel) string {
+	if level == ColorTrue || !strings.Contains(s, "\x1B[") {
+		return s
+	}
+	var dst []byte
+	for {
+		i := strings.Index(s, "\x1B[")
+		if i == -1 {
+			break
+		}
+		j := strings.IndexByte(s[i:], 'm')
+		if j == -1 {
+			break
+		}
+		dst = append(dst, s[:i]...)
+		if level != ColorNone {
+			dst = append(dst, "\x1B["...)
+			dst = append(dst, downgradeParams(s[i+2:i+j], level)...)
+			dst = append(dst, 'm')
+		}
+		s = s[i+j+1:]
+	}
+	return string(append(dst, s...))
+}
+
+// downgradeParams changes the 38 and 48 color params to the level.
+func downgradeParams(params string, level ColorLevel) string {
+	ps := strings.Split(params, ";")
+	var out []string
+	for i := 0; i < len(ps); i++ {
+		kind := ps[i]
+		var rgb uint32
+		switch {
+		case (kind == "38" || kind == "48") && i+4 < len(ps) && ps[i+1] == "2":
+			for _, p := range ps[i+2 : i+5] {
+				n, _ := strconv.Atoi(p)
+				rgb = rgb<<8 | uint32(n&0xFF)
+			}
+			i += 4
+			if level == Color256 {
+				out = append(out, kind, "5", strconv.Itoa(rgbTo256(rgb)))
+				continue
+			}
+		case (kind == "38" || kind == "48") && i+2 < len(ps) && ps[i+1] == "5":
+			if level == Color256 {
+				out = append(out, ps[i:i+3]...)
+				i += 2
+				continue
+			}
+			n, _ := strconv.Atoi(ps[i+2])
+			rgb = ansi256ToRGB(n)
+			i += 2
+		default:
+			out = append(out, kind)
+			continue
+		}
+		// the 16 colors are 30 to 37 and 90 to 97 for the foreground, and
+		// 40 to 47 and 100 to 107 for the background
+		n, base := rgbTo16(rgb), 30
+		if kind == "48" {
+			base = 40
+		}
+		if n >= 8 {
+			base += 60
+			n -= 8
+		}
+		out = append(out, strconv.Itoa(base+n))
+	}
+	return strings.Join(out, ";")
+}
+
+// ansi16 is the xterm palette of the first 16 colors.
+var ansi16 = [16]uint32{
+	0x000000, 0xcd0000, 0x00cd00, 0xcdcd00, 0x0000ee, 0xcd00cd, 0x00cdcd,
+	0xe5e5e5, 0x7f7f7f, 0xff0000, 0x00ff00, 0xffff00, 0x5c5cff, 0xff00ff,
+	0x00ffff, 0xffffff,
+}
+
+// rgbTo16 returns the nearest of the 16 colors.
+func rgbTo16(rgb uint32) int {
+	r, g, b := int(rgb>>16&0xFF), int(rgb>>8&0xFF), int(rgb&0xFF)
+	best, bestDist := 0, -1
+	for i, c := range ansi16 {
+		d := dist3(r, g, b, int(c>>16&0xFF), int(c>>8&0xFF), int(c&0xFF))
+		if bestDist == -1 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// ansi256ToRGB returns the color of one of the 256 colors.
+func ansi256ToRGB(n int) uint32 {
+	switch {
+	case n < 0 || n > 255:
+		return 0
+	case n < 16:
+		return ansi16[n]
+	case n < 232:
+		n -= 16
+		r, g, b := cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]
+		return uint32(r<<16 | g<<8 | b)
+	}
+	gray := 8 + 10*(n-232)
+	return uint32(gray<<16 | gray<<8 | gray)
+}
@@ -0,0 +1,118 @@
+package pretty
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+type fdWriter struct {
+	bytes.Buffer
+	fd uintptr
+}
+
+func (w *fdWriter) Fd() uintptr { return w.fd }
+
+func TestDetectColor(t *testing.T) {
+	tty := &fdWriter{fd: 1}
+	pipe := &fdWriter{fd: 2}
+	tests := []struct {
+		env    map[string]string
+		w      interface{ Write([]byte) (int, error) }
+		expect ColorLevel
+	}{
+		{map[string]string{"TERM": "xterm"}, tty, Color16},
+		{map[string]string{"TERM": "xterm"}, pipe, ColorNone},
+		{map[string]string{"TERM": "xterm"}, &bytes.Buffer{}, ColorNone},
+		{map[string]string{"TERM": "xterm-256color"}, tty, Color256},
+		{map[string]string{"TERM": "xterm-direct"}, tty, ColorTrue},
+		{map[string]string{"TERM": "xterm", "COLORTERM": "truecolor"}, tty,
+			ColorTrue},
+		{map[string]string{"TERM": "dumb"}, tty, ColorNone},
+		{map[string]string{"TERM": "xterm", "NO_COLOR": "1"}, tty, ColorNone},
+		{map[string]string{"TERM": "xterm", "NO_COLOR": ""}, tty, Color16},
+		{map[string]string{"FORCE_COLOR": ""}, pipe, Color16},
+		{map[string]string{"FORCE_COLOR": "2"}, pipe, Color256},
+		// NO_COLOR comes first
+		{map[string]string{"FORCE_COLOR": "3", "NO_COLOR": "1"}, pipe,
+			ColorNone},
+		{map[string]string{"FORCE_COLOR": "1", "TERM": "xterm-256color"},
+			pipe, Color256},
+		{map[string]string{"FORCE_COLOR": "0", "TERM": "xterm"}, tty,
+			ColorNone},
+		{map[string]string{"FORCE_COLOR": "false"}, tty, ColorNone},
+	}
+	for i, tt := range tests {
+		d := ColorDetector{
+			Getenv: func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			},
+			IsTerminal: func(fd uintptr) bool { return fd == 1 },
+		}
+		if level := d.Level(tt.w); level != tt.expect {
+			t.Fatalf("%d: expected %d, got %d", i, tt.expect, level)
+		}
+	}
+}
+
+func TestDetectStyle(t *testing.T) {
+	d := ColorDetector{
+		Getenv: func(key string) (string, bool) {
+			return map[string]string{"TERM": "xterm"}[key], key == "TERM"
+		},
+		IsTerminal: func(fd uintptr) bool { return true },
+	}
+	assertEqual(t, TerminalStyle.Key, d.Style(&fdWriter{}, TerminalStyle).Key)
+	// the json is as it is when there's no color
+	json := []byte("{\"a\":[1,\"\\n\x01\"]}")
+	assertEqual(t, string(json),
+		string(Color(json, d.Style(&bytes.Buffer{}, TerminalStyle))))
+	assertEqual(t, string(json), string(Color(json, DetectStyle(nil))))
+	assertEqual(t, string(json), string(ColorFor(&bytes.Buffer{}, json)))
+
+	// a 24-bit theme on a 16 color terminal
+	style, _ := StyleByName("solarized-dark")
+	res := d.Style(&fdWriter{}, style)
+	assertEqual(t, [2]string{"\x1B[36m", "\x1B[0m"}, res.String)
+	assertEqual(t, [2]string{"\x1B[38;2;42;161;152m", "\x1B[0m"}, style.String)
+}
+
+func TestDowngrade(t *testing.T) {
+	style := &Style{
+		Key:    [2]string{"\x1B[1m\x1B[38;2;255;0;0m", "\x1B[0m"},
+		String: [2]string{"\x1B[38;5;46;48;2;0;0;0m", "\x1B[0m"},
+		Null:   [2]string{"<\x1B[2m>", "\x1B[0m"},
+	}
+	res := style.Downgrade(ColorTrue)
+	assertEqual(t, *style, *res)
+	res = style.Downgrade(Color256)
+	assertEqual(t, "\x1B[1m\x1B[38;5;196m", res.Key[0])
+	assertEqual(t, "\x1B[38;5;46;48;5;16m", res.String[0])
+	res = style.Downgrade(Color16)
+	assertEqual(t, "\x1B[1m\x1B[91m", res.Key[0])
+	assertEqual(t, "\x1B[92;40m", res.String[0])
+	assertEqual(t, "<\x1B[2m>", res.Null[0])
+	res = style.Downgrade(ColorNone)
+	assertEqual(t, "", res.Key[0])
+	assertEqual(t, "", res.Key[1])
+	assertEqual(t, "<>", res.Null[0])
+
+	style.DepthBrackets = [][2]string{{"\x1B[38;2;255;0;0m", "\x1B[0m"}}
+	res = style.Downgrade(Color16)
+	assertEqual(t, "\x1B[91m", res.DepthBrackets[0][0])
+	assertEqual(t, "\x1B[38;2;255;0;0m", style.DepthBrackets[0][0])
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	d := ColorDetector{Getenv: func(key string) (string, bool) {
+		return "xterm", key == "TERM"
+	}}
+	// a character device that's not a terminal
+	assertEqual(t, ColorNone, d.Level(f))
+}
@@ -0,0 +1,349 @@
+package pretty
+
+import (
+	"bytes"
+	"io"
+)
+
+const (
+	encoderChunkSize = 4096
+	encoderFlushSize = 4096
+	encoderBufSize   = 64 * 1024
+)
+
+// Encoder writes pretty formatted JSON to an output stream.
+type Encoder struct {
+	w       io.Writer
+	opts    *Options
+	bufsize int
+
+	r     io.Reader
+	n     int64
+	rerr  error
+	werr  error
+	chunk []byte
+
+	// in holds the input that has been read but not yet formatted. It's
+	// compacted as it's read, with each run of insignificant space replaced
+	// by a single ' '.
+	in    []byte
+	pos   int
+	instr bool
+	esc   bool
+	space bool
+
+	buf []byte
+	nl  int
+	st  state
+}
+
+// NewEncoder returns a new encoder that writes to w. Passing nil to the opts
+// param will use the DefaultOptions.
+func NewEncoder(w io.Writer, opts *Options) *Encoder {
+	if opts == nil {
+		opts = DefaultOptions
+	}
+	return &Encoder{w: w, opts: opts, bufsize: encoderBufSize,
+		st: state{sorter: opts.arraySorter()}}
+}
+
+// ReadFrom reads json from r until EOF and writes the pretty formatted result
+// to the underlying writer. Each top-level value in the input is formatted
+// the same as PrettyOptions would format it, followed by a newline.
+//
+// Values that fit in the internal buffer are passed through the same code as
+// PrettyOptions. Larger arrays and objects are streamed one element at a
+// time, so the memory used is bounded by the nesting depth and the size of
+// the largest string rather than the size of the input. When SortKeys,
+// OmitNulls, or a DuplicateKeys policy that removes members, is set then each
+// object is buffered in full. When SortArrays is set then each top-level value is
+// buffered in full.
+func (e *Encoder) ReadFrom(r io.Reader) (n int64, err error) {
+	e.r, e.n, e.rerr = r, 0, nil
+	if e.chunk == nil {
+		e.chunk = make([]byte, encoderChunkSize)
+	}
+	for e.werr == nil && e.skipSpace() {
+		if !isValueStart(e.in[e.pos]) {
+			// not the start of a value, skip it
+			e.pos++
+			continue
+		}
+		e.nl = len(e.buf)
+		if len(e.opts.Prefix) != 0 {
+			e.buf = append(e.buf, e.opts.Prefix...)
+		}
+		e.value(0)
+		e.buf = append(e.buf, '\n')
+		e.flush(true)
+	}
+	e.flush(true)
+	e.in, e.pos = e.in[:0], 0
+	e.instr, e.esc, e.space = false, false, false
+	if e.werr != nil {
+		return e.n, e.werr
+	}
+	if e.rerr != io.EOF {
+		return e.n, e.rerr
+	}
+	return e.n, nil
+}
+
+func isValueStart(c byte) bool {
+	switch c {
+	case '"', '{', '[', '-', '+', 't', 'f', 'n', 'N', 'i', 'I':
+		return true
+	}
+	return c >= '0' && c <= '9'
+}
+
+// fill reads the next chunk from the reader into e.in. It returns false when
+// no more input is available.
+func (e *Encoder) fill() bool {
+	for e.rerr == nil && e.werr == nil {
+		n, err := e.r.Read(e.chunk)
+		e.n += int64(n)
+		start := len(e.in)
+		for _, c := range e.chunk[:n] {
+			if e.instr {
+				e.in = append(e.in, c)
+				if e.esc {
+					e.esc = false
+				} else if c == '\\' {
+					e.esc = true
+				} else if c == '"' {
+					e.instr = false
+				}
+			} else if c <= ' ' {
+				e.space = true
+			} else {
+				if e.space {
+					if len(e.in) > 0 {
+						e.in = append(e.in, ' ')
+					}
+					e.space = false
+				}
+				e.in = append(e.in, c)
+				if c == '"' {
+					e.instr = true
+				}
+			}
+		}
+		if err != nil {
+			e.rerr = err
+		}
+		if len(e.in) > start {
+			return true
+		}
+	}
+	return false
+}
+
+// skipSpace moves e.pos to the next non-space input byte. It returns false
+// when the input is exhausted.
+func (e *Encoder) skipSpace() bool {
+	for {
+		for ; e.pos < len(e.in); e.pos++ {
+			if e.in[e.pos] != ' ' {
+				return true
+			}
+		}
+		if !e.fill() {
+			return false
+		}
+	}
+}
+
+// scan returns the end of the value that starts at e.pos, reading more input
+// as needed. It returns -1 if the value is longer than limit, unless limit is
+// -1.
+func (e *Encoder) scan(limit int) int {
+	i := e.pos
+	c := e.in[i]
+	if c != '"' && c != '{' && c != '[' {
+		for i++; ; i++ {
+			if i == len(e.in) && !e.fill() {
+				return i
+			}
+			c := e.in[i]
+			if c == ' ' || c == ',' || c == ':' || c == ']' || c == '}' {
+				return i
+			}
+		}
+	}
+	var depth int
+	var instr bool
+	for ; ; i++ {
+		if i == len(e.in) && !e.fill() {
+			return i
+		}
+		if limit != -1 && i-e.pos >= limit {
+			return -1
+		}
+		c := e.in[i]
+		if instr {
+			if c == '\\' {
+				i++
+				if i == len(e.in) && !e.fill() {
+					return i
+				}
+			} else if c == '"' {
+				instr = false
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			instr = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+}
+
+// value formats the next value in the input.
+func (e *Encoder) value(tabs int) {
+	for {
+		if !e.skipSpace() {
+			return
+		}
+		if e.in[e.pos] != ':' {
+			break
+		}
+		e.pos++
+	}
+	if !isValueStart(e.in[e.pos]) {
+		return
+	}
+	if e.pos >= encoderChunkSize && e.pos*2 >= len(e.in) {
+		// discard the input that has already been formatted
+		e.in = e.in[:copy(e.in, e.in[e.pos:])]
+		e.pos = 0
+	}
+	limit := -1
+	if c := e.in[e.pos]; !e.opts.SortArrays && (c == '[' ||
+		(c == '{' && !e.opts.SortKeys && !e.opts.OmitNulls &&
+			e.opts.DuplicateKeys != DuplicateKeepFirst &&
+			e.opts.DuplicateKeys != DuplicateKeepLast)) {
+		limit = e.bufsize
+		if min := e.opts.Width*2 + 16; limit < min {
+			// a value that's this long can never fit on a single line
+			limit = min
+		}
+	}
+	end := e.scan(limit)
+	if end == -1 {
+		e.container(tabs)
+		return
+	}
+	e.buf, _, e.nl, _ = appendPrettyAny(e.buf, e.in[:end], e.pos, true,
+		e.opts, &e.st, tabs, e.nl, -1)
+	e.pos = end
+	e.flush(false)
+}
+
+// container formats an array or object one element at a time. The output
+// is the same as appendPrettyObject when the single line array does not fit.
+func (e *Encoder) container(tabs int) {
+	open, close := e.in[e.pos], byte('}')
+	if open == '[' {
+		close = ']'
+	}
+	e.pos++
+	style := e.opts.Style
+	e.buf, e.nl = appendPunct(e.buf, open, true, style, tabs, e.nl)
+	wrap := open == '[' && e.opts.ArrayWrap && e.opts.Width > 0
+	var scalar bool
+	var n int
+	for e.werr == nil && e.skipSpace() {
+		c := e.in[e.pos]
+		if c == ']' || c == '}' {
+			e.pos++
+			if n > 0 {
+				if e.opts.TrailingCommas && e.buf[len(e.buf)-1] != ' ' {
+					e.buf, e.nl = appendPunct(e.buf, ',', open == '{', style, tabs, e.nl)
+				}
+				e.newline()
+				e.buf = appendTabs(e.buf, e.opts.Prefix, e.opts.Indent, tabs)
+			}
+			e.buf, e.nl = appendPunct(e.buf, close, true, style, tabs, e.nl)
+			return
+		}
+		if !isValueStart(c) || (open == '{' && c != '"') {
+			e.pos++
+			continue
+		}
+		if n > 0 {
+			e.buf, e.nl = appendPunct(e.buf, ',', open == '{', style, tabs, e.nl)
+			if e.opts.Width != -1 && open == '[' {
+				e.buf = append(e.buf, ' ')
+			}
+		}
+		if wrap {
+			next := c != '{' && c != '['
+			if n > 0 && scalar && next {
+				// try to fit the element at the end of the current line
+				end := e.scan(-1)
+				s1, s2 := len(e.buf), e.nl
+				e.buf, _, e.nl, _ = appendPrettyAny(e.buf, e.in[:end], e.pos,
+					true, e.opts, &e.st, tabs+1, e.nl, -1)
+				if len(e.buf)-e.nl <= e.opts.Width {
+					e.pos = end
+					n++
+					continue
+				}
+				e.buf, e.nl = e.buf[:s1], s2
+			}
+			scalar = next
+		}
+		e.newline()
+		e.buf = appendTabs(e.buf, e.opts.Prefix, e.opts.Indent, tabs+1)
+		if open == '{' {
+			end := e.scan(-1)
+			e.buf, _, e.nl, _ = appendPrettyString(e.buf, e.in[:end], e.pos,
+				true, e.opts, e.nl)
+			e.pos = end
+			e.buf, e.nl = appendPunct(e.buf, ':', true, style, tabs, e.nl)
+			e.buf = append(e.buf, ' ')
+		}
+		e.value(tabs + 1)
+		n++
+	}
+}
+
+func (e *Encoder) newline() {
+	e.nl = len(e.buf)
+	if e.buf[e.nl-1] == ' ' {
+		e.buf[e.nl-1] = '\n'
+	} else {
+		e.buf = append(e.buf, '\n')
+	}
+}
+
+// flush writes the buffered output. Unless all is set, the current line is
+// kept in the buffer because the formatter may still need to look at it.
+func (e *Encoder) flush(all bool) {
+	n := len(e.buf)
+	if !all {
+		if n < encoderFlushSize {
+			return
+		}
+		n = bytes.LastIndexByte(e.buf, '\n')
+		if n <= 0 {
+			return
+		}
+	}
+	if e.werr == nil && n > 0 {
+		_, e.werr = e.w.Write(e.buf[:n])
+	}
+	e.buf = e.buf[:copy(e.buf, e.buf[n:])]
+	e.nl -= n
+}
@@ -0,0 +1,91 @@
+package pretty
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestEncoder(t *testing.T) {
+	big := "[" + strings.Repeat(string(example1)+",", 50) + string(example1) + "]"
+	inputs := []string{
+		string(example1),
+		example2,
+		big,
+		`{"deep":` + big + `,"name":"Janet"}`,
+		`[[` + strings.Repeat(`1234567890,`, 100) + `1]]`,
+		`[` + strings.Repeat(`"hello \" world",`, 100) + `{}]`,
+		`{}`, `[]`, `"hello"`, `123`,
+	}
+	opts := []*Options{
+		nil,
+		{Width: 40, Prefix: "> ", Indent: "\t"},
+		{Width: 80, Indent: "  ", SortKeys: true},
+		{Width: 80, Indent: "  ", DuplicateKeys: DuplicateKeepLast},
+		{Width: 80, Indent: "  ", CompactObjects: true},
+		{Width: 60, Indent: "  ", ArrayWrap: true},
+		{Width: 80, Indent: "  ", SortKeys: true, SortArrays: true},
+		{Width: 80, Indent: "  ", OmitNulls: true, Escapes: EscapeMinimal,
+			Numbers: NumbersShortest},
+		{Width: 80, Indent: "  ", TrailingCommas: true, JSON5: true},
+	}
+	for _, input := range inputs {
+		for _, o := range opts {
+			for _, bufsize := range []int{0, 1024, encoderBufSize} {
+				expect := PrettyOptions([]byte(input), o)
+				var out bytes.Buffer
+				e := NewEncoder(&out, o)
+				e.bufsize = bufsize
+				n, err := e.ReadFrom(iotest.HalfReader(strings.NewReader(input)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertEqual(t, int64(len(input)), n)
+				if out.String() != string(expect) {
+					t.Fatalf("expected '%s', got '%s'", expect, out.String())
+				}
+			}
+		}
+	}
+}
+
+func TestEncoderMultipleValues(t *testing.T) {
+	var out bytes.Buffer
+	_, err := NewEncoder(&out, nil).ReadFrom(strings.NewReader(
+		"{\"a\":1}\n[1,2]\n\"three\" 4 5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "{\n  \"a\": 1\n}\n[1, 2]\n\"three\"\n4\n5\n"
+	if out.String() != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, out.String())
+	}
+}
+
+func TestEncoderRandom(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	b := make([]byte, 1024)
+	for i := 0; i < 10000; i++ {
+		for j := range b {
+			b[j] = "{}[],:\"\\ 1an"[rand.Intn(12)]
+		}
+		var out bytes.Buffer
+		e := NewEncoder(&out, nil)
+		e.bufsize = 0
+		e.ReadFrom(bytes.NewReader(b))
+	}
+}
+
+func BenchmarkEncoder(t *testing.B) {
+	var out bytes.Buffer
+	e := NewEncoder(&out, nil)
+	t.ReportAllocs()
+	t.ResetTimer()
+	for i := 0; i < t.N; i++ {
+		out.Reset()
+		e.ReadFrom(bytes.NewReader(example1))
+	}
+}
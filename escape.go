@@ -0,0 +1,227 @@
+package pretty
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Escapes is how strings are escaped. Other than EscapeVerbatim, the
+// escapes are flags that can be combined, such as EscapeASCII|EscapeHTML.
+type Escapes int
+
+const (
+	// EscapeVerbatim keeps the strings as they are in the input
+	EscapeVerbatim Escapes = 0
+	// EscapeMinimal decodes the strings and escapes only the characters
+	// that must be escaped, which are the quote, backslash, and control
+	// characters. For example, "\u00e9\/" becomes "é/".
+	EscapeMinimal Escapes = 1
+	// EscapeASCII is like EscapeMinimal, and also escapes all non-ASCII
+	// characters, using surrogate pairs for the characters that are outside
+	// of the BMP. For example, "é😀" becomes "\u00e9\ud83d\ude00".
+	EscapeASCII Escapes = 2
+	// EscapeHTML is like EscapeMinimal, and also escapes the <, >, and &
+	// characters, and U+2028 and U+2029, the same as encoding/json does.
+	// This makes the strings safe to embed in an HTML script tag.
+	EscapeHTML Escapes = 4
+)
+
+// appendEscapedString appends the decoded string with the escapes. The
+// quote, backslash, and control characters are always escaped.
+func appendEscapedString(buf, s []byte, escapes Escapes) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		r, n := rune(s[i]), 1
+		if r >= utf8.RuneSelf {
+			r, n = utf8.DecodeRune(s[i:])
+		}
+		buf = appendEscapedRune(buf, s[i:i+n], r, escapes)
+		i += n
+	}
+	return append(buf, '"')
+}
+
+// appendReescaped appends the string token, which is quoted, with its
+// escapes decoded and then escaped again with the escapes. The bytes that
+// are not valid UTF-8 are treated the same as they are by
+// appendEscapedString, and the escapes of lone surrogates are kept, because
+// they can't be decoded. A string that's not terminated, or that has a bad
+// escape, is appended as it is.
+func appendReescaped(buf, str []byte, escapes Escapes) []byte {
+	if !isTerminated(str) {
+		return append(buf, str...)
+	}
+	mark := len(buf)
+	buf = append(buf, '"')
+	body := str[:len(str)-1]
+	var enc [utf8.UTFMax]byte
+	for i := 1; i < len(body); {
+		if body[i] != '\\' {
+			r, n := rune(body[i]), 1
+			if r >= utf8.RuneSelf {
+				r, n = utf8.DecodeRune(body[i:])
+			}
+			buf = appendEscapedRune(buf, body[i:i+n], r, escapes)
+			i += n
+			continue
+		}
+		if i+1 == len(body) {
+			return append(buf[:mark], str...)
+		}
+		r, n := rune(body[i+1]), 2
+		switch r {
+		case '"', '\\', '/':
+		case 'b':
+			r = '\b'
+		case 'f':
+			r = '\f'
+		case 'n':
+			r = '\n'
+		case 'r':
+			r = '\r'
+		case 't':
+			r = '\t'
+		case 'u':
+			if !isUnicodeEscape(body, i) {
+				return append(buf[:mark], str...)
+			}
+			r, n = hexRune(body[i+2:i+6]), 6
+			if utf16.IsSurrogate(r) && isUnicodeEscape(body, i+6) {
+				if r2 := utf16.DecodeRune(r, hexRune(body[i+8:i+12])); r2 != utf8.RuneError {
+					r, n = r2, 12
+				}
+			}
+			if utf16.IsSurrogate(r) {
+				buf = appendUnicodeEscape(buf, r)
+				i += n
+				continue
+			}
+		default:
+			return append(buf[:mark], str...)
+		}
+		buf = appendEscapedRune(buf, enc[:utf8.EncodeRune(enc[:], r)], r, escapes)
+		i += n
+	}
+	return append(buf, '"')
+}
+
+// isTerminated returns true if the string token ends with a quote that's
+// not escaped.
+func isTerminated(str []byte) bool {
+	if len(str) < 2 || str[0] != '"' || str[len(str)-1] != '"' {
+		return false
+	}
+	var n int
+	for i := len(str) - 2; i > 0 && str[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 0
+}
+
+// appendEscapedRune appends the character, which is the bytes b that decode
+// to r, with the escapes. A byte that's not valid UTF-8 is RuneError, and
+// it's kept as it is unless it's escaped as ASCII.
+func appendEscapedRune(buf, b []byte, r rune, escapes Escapes) []byte {
+	if r >= utf8.RuneSelf {
+		switch {
+		case escapes&EscapeASCII != 0:
+			if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+				buf = appendUnicodeEscape(buf, r1)
+				return appendUnicodeEscape(buf, r2)
+			}
+			return appendUnicodeEscape(buf, r)
+		case escapes&EscapeHTML != 0 && (r == '\u2028' || r == '\u2029'):
+			return appendUnicodeEscape(buf, r)
+		}
+		return append(buf, b...)
+	}
+	c := byte(r)
+	switch c {
+	case '"', '\\':
+		return append(buf, '\\', c)
+	case '\b':
+		return append(buf, '\\', 'b')
+	case '\f':
+		return append(buf, '\\', 'f')
+	case '\n':
+		return append(buf, '\\', 'n')
+	case '\r':
+		return append(buf, '\\', 'r')
+	case '\t':
+		return append(buf, '\\', 't')
+	case '<', '>', '&':
+		if escapes&EscapeHTML != 0 {
+			return appendUnicodeEscape(buf, r)
+		}
+	default:
+		if c < ' ' {
+			return appendUnicodeEscape(buf, r)
+		}
+	}
+	return append(buf, c)
+}
+
+// appendUnicodeEscape appends the \uXXXX escape of a BMP character or a
+// surrogate.
+func appendUnicodeEscape(buf []byte, r rune) []byte {
+	return append(buf, '\\', 'u', hexp(byte(r>>12&0xF)), hexp(byte(r>>8&0xF)),
+		hexp(byte(r>>4&0xF)), hexp(byte(r&0xF)))
+}
+
+// isIdentifier returns true if the decoded key can be written without quotes
+// in JSON5. With EscapeASCII the key must also be ASCII.
+func isIdentifier(name []byte, escapes Escapes) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i := 0; i < len(name); {
+		r, n := utf8.DecodeRune(name[i:])
+		if (r >= utf8.RuneSelf && escapes&EscapeASCII != 0) ||
+			!isIdentifierRune(r, i > 0) {
+			return false
+		}
+		i += n
+	}
+	return true
+}
+
+// singleQuotes returns true if the string has more escaped double quotes
+// than single quotes, which is when it's shorter in single quotes.
+func singleQuotes(str []byte) bool {
+	if len(str) < 2 || str[0] != '"' || str[len(str)-1] != '"' {
+		return false
+	}
+	var n int
+	for i := 1; i < len(str)-1; i++ {
+		switch str[i] {
+		case '\\':
+			i++
+			if str[i] == '"' {
+				n++
+			}
+		case '\'':
+			n--
+		}
+	}
+	return n > 0
+}
+
+// appendSingleQuoted appends the double quoted string in single quotes.
+func appendSingleQuoted(buf, str []byte) []byte {
+	buf = append(buf, '\'')
+	for i := 1; i < len(str)-1; i++ {
+		switch str[i] {
+		case '\\':
+			i++
+			if str[i] != '"' {
+				buf = append(buf, '\\')
+			}
+			buf = append(buf, str[i])
+		case '\'':
+			buf = append(buf, '\\', '\'')
+		default:
+			buf = append(buf, str[i])
+		}
+	}
+	return append(buf, '\'')
+}
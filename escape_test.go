@@ -0,0 +1,62 @@
+package pretty
+
+import "testing"
+
+func TestEscapes(t *testing.T) {
+	json := `{"café":"a\/bA\t\"\\\u001f😀","x":"plain"}`
+	opts := Options{Escapes: EscapeMinimal}
+	res := string(UglyOptions([]byte(json), &opts))
+	expect := `{"café":"a/bA\t\"\\\u001f😀","x":"plain"}`
+	assertEqual(t, expect, res)
+
+	// the styled output is the same as coloring the plain output
+	opts = Options{Width: 80, Indent: "  ", Escapes: EscapeMinimal,
+		Style: TerminalStyle}
+	res = string(PrettyOptions([]byte(json), &opts))
+	opts.Style = nil
+	expect = string(Color(PrettyOptions([]byte(json), &opts), TerminalStyle))
+	assertEqual(t, expect, res)
+}
+
+func TestEscapesASCII(t *testing.T) {
+	json := "{\"café\":\"<a href='x'>&amp;</a> \u2028é😀\\u00e9\"}"
+	tests := []struct {
+		escapes Escapes
+		expect  string
+	}{
+		{EscapeASCII, `{"caf\u00e9":"<a href='x'>&amp;</a> \u2028` +
+			`\u00e9\ud83d\ude00\u00e9"}`},
+		{EscapeHTML, "{\"café\":\"\\u003ca href='x'\\u003e\\u0026amp;" +
+			"\\u003c/a\\u003e \\u2028é😀é\"}"},
+		{EscapeASCII | EscapeHTML, `{"caf\u00e9":"\u003ca href='x'\u003e` +
+			`\u0026amp;\u003c/a\u003e \u2028\u00e9\ud83d\ude00\u00e9"}`},
+	}
+	for _, tt := range tests {
+		opts := Options{Escapes: tt.escapes}
+		res := string(UglyOptions([]byte(json), &opts))
+		assertEqual(t, tt.expect, res)
+	}
+}
+
+func TestEscapesDecode(t *testing.T) {
+	tests := []struct {
+		json   string
+		expect string
+	}{
+		// invalid UTF-8 is the same with and without escapes
+		{"[\"a\xff\",\"a\xff\\n\"]", "[\"a\xff\",\"a\xff\\n\"]"},
+		// lone surrogates are kept
+		{`["\ud800x","\uDC00","\ud83d\ude00","\ud800A"]`,
+			`["\ud800x","\udc00","😀","\ud800A"]`},
+		// strings that can't be decoded are kept as they are
+		{`["a\xé"]`, `["a\xé"]`},
+		{`["a\u00e"]`, `["a\u00e"]`},
+		{`["abc\n`, `["abc\n`},
+		{`["abc\"`, `["abc\"`},
+	}
+	for _, tt := range tests {
+		opts := Options{Escapes: EscapeMinimal}
+		res := string(UglyOptions([]byte(tt.json), &opts))
+		assertEqual(t, tt.expect, res)
+	}
+}
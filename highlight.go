@@ -0,0 +1,349 @@
+package pretty
+
+import (
+	"bytes"
+	"regexp"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ColorOptions is the options for ColorWithOptions and ColorE
+type ColorOptions struct {
+	// Style is the colors, where Match is the color of the highlights
+	// Default is nil, which is TerminalStyle
+	Style *Style
+	// Highlight is the keys and values to highlight
+	// Default is nil, which highlights nothing
+	Highlight *Highlight
+	// InvalidUTF8 is the policy for strings that have invalid UTF-8 bytes, or
+	// escapes of lone surrogates, the same as the InvalidUTF8 of Options
+	// Default is UTF8Pass
+	InvalidUTF8 UTF8Policy
+	// DuplicateKeys is the policy for objects that have the same key more
+	// than once. Only DuplicateError is used, by ColorE, as the colored
+	// output keeps every member.
+	// Default is DuplicateKeepAll
+	DuplicateKeys DuplicatePolicy
+}
+
+// Highlight is a query for the keys and values to highlight. The parts that
+// match any of the fields are highlighted.
+type Highlight struct {
+	// Substring highlights where the keys and values contain the string. The
+	// strings are matched after their escapes are decoded.
+	Substring string
+	// Regexp highlights where the keys and values match, the same as
+	// Substring
+	Regexp *regexp.Regexp
+	// Path highlights all of the key and value at the path, such as
+	// "users.#.name", using the same syntax as SortArrayPaths. The brackets
+	// are highlighted for arrays and objects.
+	Path string
+}
+
+// ColorWithOptions is like Color but with options for highlighting.
+func ColorWithOptions(src []byte, opts *ColorOptions) []byte {
+	var style *Style
+	var h *highlighter
+	var fix UTF8Policy
+	if opts != nil {
+		style = opts.Style
+		h = newHighlighter(opts.Highlight)
+		if opts.InvalidUTF8 == UTF8Replace || opts.InvalidUTF8 == UTF8Escape {
+			fix = opts.InvalidUTF8
+		}
+	}
+	if style == nil {
+		style = TerminalStyle
+	}
+	apnd := style.Append
+	if apnd == nil {
+		apnd = appendByte
+	}
+	type stackt struct {
+		kind byte
+		key  bool
+		// name is the last key of an object
+		name string
+		// matched is when the path of the array or object is highlighted
+		matched bool
+	}
+	var dst []byte
+	var stack []stackt
+	// pathMatch returns true when the value at the current position is at
+	// the highlighted path.
+	pathMatch := func() bool {
+		if h == nil || h.paths == nil {
+			return false
+		}
+		if len(stack) == 0 {
+			return matchPaths(h.paths, h.path)
+		}
+		elem := pathElem{index: true}
+		if top := stack[len(stack)-1]; top.kind == '{' {
+			elem = pathElem{key: top.name}
+		}
+		return matchPaths(h.paths, append(h.path, elem))
+	}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '"' {
+			key := len(stack) > 0 && stack[len(stack)-1].key
+			if h != nil || fix != UTF8Pass {
+				j := stringEnd(src, i)
+				str := src[i:j]
+				if fix != UTF8Pass {
+					if k, _ := nextInvalid(str, 0); k != -1 {
+						str = appendFixedString(nil, str, fix)
+					}
+				}
+				var marks []bool
+				if h != nil {
+					if key && h.paths != nil {
+						stack[len(stack)-1].name = string(parsestr(str))
+					}
+					marks = h.marks(str, true, pathMatch())
+				}
+				if marks != nil {
+					color := style.String
+					if key {
+						color = style.Key
+					}
+					dst = appendHighlighted(dst, str, true, color, style, marks)
+				} else {
+					dst, _ = appendColorString(dst, str, 0, key, style)
+				}
+				i = j - 1
+				continue
+			}
+			dst, i = appendColorString(dst, src, i, key, style)
+		} else if src[i] == '{' || src[i] == '[' {
+			matched := pathMatch()
+			if h != nil && h.paths != nil && len(stack) > 0 {
+				elem := pathElem{index: true}
+				if top := stack[len(stack)-1]; top.kind == '{' {
+					elem = pathElem{key: top.name}
+				}
+				h.path = append(h.path, elem)
+			}
+			stack = append(stack, stackt{src[i], src[i] == '{', "", matched})
+			dst = appendBracket(dst, src[i], len(stack)-1, matched, style, apnd)
+		} else if (src[i] == '}' || src[i] == ']') && len(stack) > 0 {
+			matched := stack[len(stack)-1].matched
+			stack = stack[:len(stack)-1]
+			if h != nil && len(h.path) > 0 && len(stack) > 0 {
+				h.path = h.path[:len(h.path)-1]
+			}
+			dst = appendBracket(dst, src[i], len(stack), matched, style, apnd)
+		} else if (src[i] == ':' || src[i] == ',') && len(stack) > 0 && stack[len(stack)-1].kind == '{' {
+			stack[len(stack)-1].key = !stack[len(stack)-1].key
+			dst = appendBracket(dst, src[i], len(stack)-1, false, style, apnd)
+		} else {
+			var color [2]string
+			if (src[i] >= '0' && src[i] <= '9') || src[i] == '-' || isNaNOrInf(src[i:]) {
+				color = style.Number
+			} else if src[i] == 't' {
+				color = style.True
+			} else if src[i] == 'f' {
+				color = style.False
+			} else if src[i] == 'n' {
+				color = style.Null
+			} else {
+				dst = apnd(dst, src[i])
+				continue
+			}
+			j := i
+			for ; j < len(src); j++ {
+				if src[j] <= ' ' || src[j] == ',' || src[j] == ':' || src[j] == ']' || src[j] == '}' {
+					break
+				}
+			}
+			var marks []bool
+			if h != nil {
+				marks = h.marks(src[i:j], false, pathMatch())
+			}
+			dst = appendHighlighted(dst, src[i:j], false, color, style, marks)
+			i = j - 1
+		}
+	}
+	return dst
+}
+
+// appendBracket appends one of the {}[]:, characters in the brackets color
+// of the depth, or the match color when it's highlighted. The : and ,
+// characters have the depth of their object.
+func appendBracket(dst []byte, c byte, depth int, matched bool, style *Style, apnd func([]byte, byte) []byte) []byte {
+	color := style.brackets(depth)
+	if matched {
+		color = style.Match
+	}
+	dst = append(dst, color[0]...)
+	dst = apnd(dst, c)
+	return append(dst, color[1]...)
+}
+
+type highlighter struct {
+	substring []byte
+	re        *regexp.Regexp
+	paths     [][]string
+	// path is the path of the current array or object
+	path []pathElem
+}
+
+func newHighlighter(hl *Highlight) *highlighter {
+	if hl == nil || (hl.Substring == "" && hl.Regexp == nil && hl.Path == "") {
+		return nil
+	}
+	h := &highlighter{substring: []byte(hl.Substring), re: hl.Regexp}
+	if hl.Path != "" {
+		h.paths = [][]string{splitPath(hl.Path)}
+	}
+	return h
+}
+
+// marks returns which bytes of the token are highlighted, or nil when none
+// are. All of the token is highlighted when it's at the path.
+func (h *highlighter) marks(tok []byte, str bool, path bool) []bool {
+	marks := make([]bool, len(tok))
+	if path {
+		for i := range marks {
+			marks[i] = true
+		}
+		return marks
+	}
+	if len(h.substring) == 0 && h.re == nil {
+		return nil
+	}
+	text, starts, ends := tok, []int(nil), []int(nil)
+	if str {
+		text, starts, ends = decodeString(tok)
+	}
+	var found bool
+	mark := func(a, b int) {
+		if a == b {
+			return
+		}
+		found = true
+		if str {
+			a, b = starts[a], ends[b-1]
+		}
+		for ; a < b; a++ {
+			marks[a] = true
+		}
+	}
+	if len(h.substring) > 0 {
+		for i := 0; ; {
+			j := bytes.Index(text[i:], h.substring)
+			if j == -1 {
+				break
+			}
+			mark(i+j, i+j+len(h.substring))
+			i += j + len(h.substring)
+		}
+	}
+	if h.re != nil {
+		for _, loc := range h.re.FindAllIndex(text, -1) {
+			mark(loc[0], loc[1])
+		}
+	}
+	if !found {
+		return nil
+	}
+	return marks
+}
+
+// decodeString decodes the string token, without the quotes. For each byte
+// of the text, starts and ends are the range of the token that it's from,
+// which is the whole escape for an escaped character.
+func decodeString(tok []byte) (text []byte, starts, ends []int) {
+	end := len(tok)
+	if end > 1 && tok[end-1] == '"' {
+		end--
+	}
+	var buf [utf8.UTFMax]byte
+	for i := 1; i < end; {
+		s := i
+		b := tok[i : i+1]
+		i++
+		if tok[s] == '\\' && i < end {
+			c := tok[i]
+			i++
+			switch c {
+			case 'b':
+				b = []byte{'\b'}
+			case 'f':
+				b = []byte{'\f'}
+			case 'n':
+				b = []byte{'\n'}
+			case 'r':
+				b = []byte{'\r'}
+			case 't':
+				b = []byte{'\t'}
+			case 'u':
+				if !isUnicodeEscape(tok[:end], s) {
+					b = tok[s:i]
+					break
+				}
+				r := hexRune(tok[s+2 : s+6])
+				i = s + 6
+				if utf16.IsSurrogate(r) && isUnicodeEscape(tok[:end], i) {
+					if r2 := hexRune(tok[i+2 : i+6]); utf16.DecodeRune(r, r2) != utf8.RuneError {
+						r = utf16.DecodeRune(r, r2)
+						i += 6
+					}
+				}
+				b = buf[:utf8.EncodeRune(buf[:], r)]
+			default:
+				b = []byte{c}
+			}
+		}
+		for range b {
+			starts = append(starts, s)
+			ends = append(ends, i)
+		}
+		text = append(text, b...)
+	}
+	return text, starts, ends
+}
+
+// appendHighlighted appends the token in its color, with the escapes of a
+// string in the escape color, and the marked bytes in the match color.
+func appendHighlighted(dst, tok []byte, str bool, color [2]string, style *Style, marks []bool) []byte {
+	apnd := style.Append
+	if apnd == nil {
+		apnd = appendByte
+	}
+	// the class of each byte, which is 0 for the color, 1 for an escape,
+	// and 2 for a match
+	class := make([]byte, len(tok))
+	if str {
+		for i := 1; i < len(tok)-1; i++ {
+			if tok[i] == '\\' {
+				n := 2
+				if tok[i+1] == 'u' {
+					n = 6
+				}
+				for j := i; j < i+n && j < len(tok)-1; j++ {
+					class[j] = 1
+				}
+				i += n - 1
+			}
+		}
+	}
+	for i, marked := range marks {
+		if marked {
+			class[i] = 2
+		}
+	}
+	colors := [3][2]string{color, style.Escape, style.Match}
+	for i := 0; i < len(tok); {
+		c := colors[class[i]]
+		dst = append(dst, c[0]...)
+		j := i
+		for ; j < len(tok) && class[j] == class[i]; j++ {
+			dst = apnd(dst, tok[j])
+		}
+		dst = append(dst, c[1]...)
+		i = j
+	}
+	return dst
+}
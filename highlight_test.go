@@ -0,0 +1,115 @@
+package pretty
+
+import (
+	"regexp"
+	"testing"
+)
+
+var tagStyle = &Style{
+	Key:      [2]string{"<k>", "</k>"},
+	String:   [2]string{"<s>", "</s>"},
+	Number:   [2]string{"<n>", "</n>"},
+	True:     [2]string{"<t>", "</t>"},
+	False:    [2]string{"<f>", "</f>"},
+	Null:     [2]string{"<0>", "</0>"},
+	Escape:   [2]string{"<e>", "</e>"},
+	Brackets: [2]string{"<b>", "</b>"},
+	Match:    [2]string{"<m>", "</m>"},
+}
+
+func TestColorWithOptions(t *testing.T) {
+	json := []byte(`{"name":"Jane","tags":["plain","jane\tdoe"],"age":37}`)
+	tests := []struct {
+		hl     *Highlight
+		expect string
+	}{
+		{nil, `<b>{</b><k>"name"</k><b>:</b><s>"Jane"</s><b>,</b>` +
+			`<k>"tags"</k><b>:</b><b>[</b><s>"plain"</s>,` +
+			`<s>"jane</s><e>\t</e><s>doe"</s><b>]</b><b>,</b>` +
+			`<k>"age"</k><b>:</b><n>37</n><b>}</b>`},
+		{&Highlight{Substring: "an"}, `<b>{</b><k>"name"</k><b>:</b>` +
+			`<s>"J</s><m>an</m><s>e"</s><b>,</b>` +
+			`<k>"tags"</k><b>:</b><b>[</b><s>"plain"</s>,` +
+			`<s>"j</s><m>an</m><s>e</s><e>\t</e><s>doe"</s><b>]</b><b>,</b>` +
+			`<k>"age"</k><b>:</b><n>37</n><b>}</b>`},
+		// the match includes the escape
+		{&Highlight{Substring: "e\td"}, `<b>{</b><k>"name"</k><b>:</b>` +
+			`<s>"Jane"</s><b>,</b>` +
+			`<k>"tags"</k><b>:</b><b>[</b><s>"plain"</s>,` +
+			`<s>"jan</s><m>e\td</m><s>oe"</s><b>]</b><b>,</b>` +
+			`<k>"age"</k><b>:</b><n>37</n><b>}</b>`},
+		{&Highlight{Regexp: regexp.MustCompile(`^a|7`)}, `<b>{</b>` +
+			`<k>"name"</k><b>:</b><s>"Jane"</s><b>,</b>` +
+			`<k>"tags"</k><b>:</b><b>[</b><s>"plain"</s>,` +
+			`<s>"jane</s><e>\t</e><s>doe"</s><b>]</b><b>,</b>` +
+			`<k>"</k><m>a</m><k>ge"</k><b>:</b><n>3</n><m>7</m><b>}</b>`},
+		{&Highlight{Path: "tags"}, `<b>{</b><k>"name"</k><b>:</b>` +
+			`<s>"Jane"</s><b>,</b>` +
+			`<m>"tags"</m><b>:</b><m>[</m><s>"plain"</s>,` +
+			`<s>"jane</s><e>\t</e><s>doe"</s><m>]</m><b>,</b>` +
+			`<k>"age"</k><b>:</b><n>37</n><b>}</b>`},
+		{&Highlight{Path: "tags.#"}, `<b>{</b><k>"name"</k><b>:</b>` +
+			`<s>"Jane"</s><b>,</b>` +
+			`<k>"tags"</k><b>:</b><b>[</b><m>"plain"</m>,` +
+			`<m>"jane\tdoe"</m><b>]</b><b>,</b>` +
+			`<k>"age"</k><b>:</b><n>37</n><b>}</b>`},
+	}
+	for _, tt := range tests {
+		res := ColorWithOptions(json, &ColorOptions{Style: tagStyle,
+			Highlight: tt.hl})
+		assertEqual(t, tt.expect, string(res))
+	}
+	// without a highlight it's the same as Color
+	assertEqual(t, string(Color(json, nil)),
+		string(ColorWithOptions(json, &ColorOptions{})))
+}
+
+func TestHighlightEscapes(t *testing.T) {
+	// the decoded text is matched, and a match that's part of an escape
+	// highlights the whole escape
+	json := []byte(`["caf\u00e9 \ud83d\ude00!"]`)
+	res := ColorWithOptions(json, &ColorOptions{Style: tagStyle,
+		Highlight: &Highlight{Substring: "é \U0001F600"}})
+	expect := `<b>[</b><s>"caf</s><m>\u00e9 \ud83d\ude00</m><s>!"</s><b>]</b>`
+	assertEqual(t, expect, string(res))
+
+	res = ColorWithOptions(json, &ColorOptions{Style: tagStyle,
+		Highlight: &Highlight{Regexp: regexp.MustCompile(`\x{1F600}`)}})
+	expect = `<b>[</b><s>"caf</s><e>\u00e9</e><s> </s>` +
+		`<m>\ud83d\ude00</m><s>!"</s><b>]</b>`
+	assertEqual(t, expect, string(res))
+}
+
+func TestHighlightNested(t *testing.T) {
+	json := []byte(`{"users":[{"name":"a","roles":["x"]},{"name":"b"}]}`)
+	res := ColorWithOptions(json, &ColorOptions{Style: tagStyle,
+		Highlight: &Highlight{Path: "users.#.name"}})
+	expect := `<b>{</b><k>"users"</k><b>:</b><b>[</b><b>{</b>` +
+		`<m>"name"</m><b>:</b><m>"a"</m><b>,</b><k>"roles"</k><b>:</b>` +
+		`<b>[</b><s>"x"</s><b>]</b><b>}</b>,<b>{</b>` +
+		`<m>"name"</m><b>:</b><m>"b"</m><b>}</b><b>]</b><b>}</b>`
+	assertEqual(t, expect, string(res))
+}
+
+func TestDepthBrackets(t *testing.T) {
+	style := *tagStyle
+	style.DepthBrackets = [][2]string{{"<0>", "</0>"}, {"<1>", "</1>"}}
+	style.Null = [2]string{"<n>", "</n>"}
+	res := Color([]byte(`{"a":[{"b":null},[]],"c":{}}`), &style)
+	expect := `<0>{</0><k>"a"</k><0>:</0><1>[</1><0>{</0><k>"b"</k><0>:</0>` +
+		`<n>null</n><0>}</0>,<0>[</0><0>]</0><1>]</1><0>,</0>` +
+		`<k>"c"</k><0>:</0><1>{</1><1>}</1><0>}</0>`
+	assertEqual(t, expect, string(res))
+
+	// a highlighted bracket is still the match color
+	res = ColorWithOptions([]byte(`{"a":{}}`), &ColorOptions{Style: &style,
+		Highlight: &Highlight{Path: "a"}})
+	expect = `<0>{</0><m>"a"</m><0>:</0><m>{</m><m>}</m><0>}</0>`
+	assertEqual(t, expect, string(res))
+
+	res = Color([]byte(`[[[]]]`), TerminalRainbowStyle)
+	expect = "\x1B[1m\x1B[93m[\x1B[0m\x1B[1m\x1B[95m[\x1B[0m" +
+		"\x1B[1m\x1B[96m[\x1B[0m\x1B[1m\x1B[96m]\x1B[0m" +
+		"\x1B[1m\x1B[95m]\x1B[0m\x1B[1m\x1B[93m]\x1B[0m"
+	assertEqual(t, expect, string(res))
+}
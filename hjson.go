@@ -0,0 +1,281 @@
+package pretty
+
+import "bytes"
+
+// HJSONToJSON converts HJSON, https://hjson.github.io, to JSON. It returns a
+// *SyntaxError, with the line and column of the error, when the input is not
+// valid HJSON.
+//
+// Like Spec, the comments are replaced with spaces, and the line breaks are
+// kept on the same lines. The line breaks in multiline strings are moved to
+// just after the string.
+func HJSONToJSON(src []byte) ([]byte, error) {
+	c := hjson{json5{src: src, dst: make([]byte, 0, len(src)), hash: true}}
+	i, exp := c.space(0)
+	if exp == "" {
+		if i < len(src) && (src[i] == '{' || src[i] == '[') {
+			i, exp = c.value(i)
+		} else {
+			i, exp = c.root(i)
+		}
+		if exp == "" {
+			if i, exp = c.space(i); exp == "" && i < len(src) {
+				exp = "end of json"
+			}
+		}
+	}
+	if exp != "" {
+		return nil, newSyntaxError(src, i, exp)
+	}
+	return c.dst, nil
+}
+
+type hjson struct {
+	json5
+}
+
+// root converts a root object that does not have braces, which is when the
+// input starts with a key and a colon, or otherwise a single value.
+func (c *hjson) root(i int) (int, string) {
+	mark := len(c.dst)
+	j, exp := c.key(i)
+	if exp == "" {
+		j, exp = c.space(j)
+	}
+	c.dst = c.dst[:mark]
+	if exp != "" || j == len(c.src) || c.src[j] != ':' {
+		return c.value(i)
+	}
+	c.dst = append(c.dst, '{')
+	if i, exp = c.members(i, 0); exp == "" {
+		c.dst = append(c.dst, '}')
+	}
+	return i, exp
+}
+
+func (c *hjson) value(i int) (int, string) {
+	if i == len(c.src) {
+		return i, "value"
+	}
+	switch c.src[i] {
+	case '{':
+		c.dst = append(c.dst, '{')
+		i, exp := c.members(i+1, '}')
+		if exp == "" {
+			c.dst = append(c.dst, '}')
+		}
+		return i, exp
+	case '[':
+		c.dst = append(c.dst, '[')
+		i, exp := c.members(i+1, ']')
+		if exp == "" {
+			c.dst = append(c.dst, ']')
+		}
+		return i, exp
+	case '"':
+		return c.string(i)
+	case '\'':
+		if bytes.HasPrefix(c.src[i:], []byte("'''")) {
+			return c.multiline(i)
+		}
+		return c.string(i)
+	case ',', ':', ']', '}':
+		return i, "value"
+	}
+	return c.quoteless(i)
+}
+
+// members converts the members of an object, or the elements of an array,
+// up to the close byte. The members are separated by commas or line breaks.
+// A zero close is for a root object, which ends at the end of the input.
+func (c *hjson) members(i int, close byte) (int, string) {
+	var exp string
+	var n, end int
+	var sep bool
+	comma := -1
+	for {
+		start := i
+		if i, exp = c.space(i); exp != "" {
+			return i, exp
+		}
+		sep = sep || bytes.IndexByte(c.src[start:i], '\n') != -1
+		if i == len(c.src) || (close != 0 && c.src[i] == close) {
+			if close != 0 && i == len(c.src) {
+				return i, "'" + string(close) + "'"
+			}
+			if comma != -1 {
+				// trailing comma
+				c.dst[comma] = ' '
+			}
+			if close == 0 {
+				return i, ""
+			}
+			return i + 1, ""
+		}
+		if n > 0 {
+			if !sep {
+				switch close {
+				case ']':
+					return i, "',' or ']'"
+				case '}':
+					return i, "',' or '}'"
+				}
+				return i, "',' or line break"
+			}
+			if comma == -1 {
+				// the members are separated by a line break
+				c.dst = append(c.dst, 0)
+				copy(c.dst[end+1:], c.dst[end:])
+				c.dst[end] = ','
+			}
+		}
+		if close != ']' {
+			if i, exp = c.key(i); exp != "" {
+				return i, exp
+			}
+			if i, exp = c.space(i); exp != "" {
+				return i, exp
+			}
+			if i == len(c.src) || c.src[i] != ':' {
+				return i, "':'"
+			}
+			c.dst = append(c.dst, ':')
+			if i, exp = c.space(i + 1); exp != "" {
+				return i, exp
+			}
+		}
+		if i, exp = c.value(i); exp != "" {
+			return i, exp
+		}
+		end = len(c.dst)
+		n++
+		sep, comma = false, -1
+		start = i
+		if i, exp = c.space(i); exp != "" {
+			return i, exp
+		}
+		sep = bytes.IndexByte(c.src[start:i], '\n') != -1
+		if i < len(c.src) && c.src[i] == ',' {
+			comma = len(c.dst)
+			c.dst = append(c.dst, ',')
+			sep = true
+			i++
+		}
+	}
+}
+
+// key converts a quoted or quoteless key.
+func (c *hjson) key(i int) (int, string) {
+	if i == len(c.src) {
+		return i, "key"
+	}
+	if c.src[i] == '"' || c.src[i] == '\'' {
+		return c.string(i)
+	}
+	j := i
+	for ; j < len(c.src); j++ {
+		ch := c.src[j]
+		if ch <= ' ' || ch == ',' || ch == ':' || ch == '[' || ch == ']' ||
+			ch == '{' || ch == '}' {
+			break
+		}
+	}
+	if j == i {
+		return i, "key"
+	}
+	c.dst = appendEscapedString(c.dst, c.src[i:j], EscapeMinimal)
+	return j, ""
+}
+
+// quoteless converts a number or literal, or a quoteless string that runs
+// to the end of the line.
+func (c *hjson) quoteless(i int) (int, string) {
+	j := i
+	switch c.src[i] {
+	case 't':
+		j, _ = validLiteral(c.src, i, "true")
+	case 'f':
+		j, _ = validLiteral(c.src, i, "false")
+	case 'n':
+		j, _ = validLiteral(c.src, i, "null")
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		if k, exp := validNumber(c.src, i); exp == "" {
+			j = k
+		}
+	}
+	if j > i && c.endOfValue(j) {
+		c.dst = append(c.dst, c.src[i:j]...)
+		return j, ""
+	}
+	for j = i; j < len(c.src) && c.src[j] != '\n'; j++ {
+	}
+	for j > i && (c.src[j-1] == ' ' || c.src[j-1] == '\t' ||
+		c.src[j-1] == '\r') {
+		j--
+	}
+	c.dst = appendEscapedString(c.dst, c.src[i:j], EscapeMinimal)
+	return j, ""
+}
+
+// endOfValue returns true if a number or literal that ends at i is not the
+// start of a quoteless string.
+func (c *hjson) endOfValue(i int) bool {
+	for ; i < len(c.src) && (c.src[i] == ' ' || c.src[i] == '\t'); i++ {
+	}
+	if i == len(c.src) {
+		return true
+	}
+	switch c.src[i] {
+	case '\n', '\r', ',', ']', '}', '#':
+		return true
+	case '/':
+		return i+1 < len(c.src) && (c.src[i+1] == '/' || c.src[i+1] == '*')
+	}
+	return false
+}
+
+// multiline converts a triple quoted string. The indentation of the opening
+// quotes is removed from each line, along with the line break that follows
+// the opening quotes and the one that comes before the closing quotes.
+func (c *hjson) multiline(i int) (int, string) {
+	var indent int
+	for j := i - 1; j >= 0 && c.src[j] != '\n'; j-- {
+		indent++
+	}
+	i += 3
+	var text, tail []byte
+	j := i
+	for ; j < len(c.src) && (c.src[j] == ' ' || c.src[j] == '\t' ||
+		c.src[j] == '\r'); j++ {
+	}
+	if j < len(c.src) && c.src[j] == '\n' {
+		tail = append(tail, '\n')
+		i = j + 1
+	}
+	for col := 0; ; i++ {
+		if i+3 > len(c.src) {
+			return len(c.src), "'''"
+		}
+		if c.src[i] == '\'' && c.src[i+1] == '\'' && c.src[i+2] == '\'' {
+			break
+		}
+		switch ch := c.src[i]; {
+		case ch == '\n':
+			text = append(text, '\n')
+			tail = append(tail, '\n')
+			col = 0
+		case ch == '\r':
+		case (ch == ' ' || ch == '\t') && col < indent:
+			col++
+		default:
+			text = append(text, ch)
+			col = indent
+		}
+	}
+	if len(text) > 0 && text[len(text)-1] == '\n' {
+		text = text[:len(text)-1]
+	}
+	c.dst = appendEscapedString(c.dst, text, EscapeMinimal)
+	c.dst = append(c.dst, tail...)
+	return i + 3, ""
+}
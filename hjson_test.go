@@ -0,0 +1,91 @@
+package pretty
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHJSONToJSON(t *testing.T) {
+	src := `# the server config
+name: hello, world  # not a comment
+port: 8080
+hosts: [
+  a.example.com
+  "b.example.com", 'c.example.com'
+]
+limits: {max: 3, min: 1, enabled: true}
+motd:
+  '''
+  Welcome!
+    Enjoy your stay.
+  '''
+path: "/var/log" // a comment
+`
+	res, err := HJSONToJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"name":"hello, world  # not a comment","port":8080,` +
+		`"hosts":["a.example.com","b.example.com","c.example.com"],` +
+		`"limits":{"max":3,"min":1,"enabled":true},` +
+		`"motd":"Welcome!\n  Enjoy your stay.","path":"/var/log"}`
+	assertEqual(t, expect, string(Ugly(res)))
+	assertEqual(t, bytes.Count([]byte(src), []byte("\n")),
+		bytes.Count(res, []byte("\n")))
+	i := bytes.Index([]byte(src), []byte("path"))
+	j := bytes.Index(res, []byte("path"))
+	assertEqual(t, bytes.Count([]byte(src[:i]), []byte("\n")),
+		bytes.Count(res[:j], []byte("\n")))
+
+	tests := []struct{ src, expect string }{
+		{`[1,2,]`, `[1,2]`},
+		{"[\n  1\n  2\n]", `[1,2]`},
+		{`just a string`, `"just a string"`},
+		{`42`, `42`},
+		{"a: 1\nb: -2.5e3 # c\nc: null", `{"a":1,"b":-2.5e3,"c":null}`},
+		{`tabs: a	"b"\`, `{"tabs":"a\t\"b\"\\"}`},
+	}
+	for _, tt := range tests {
+		res, err := HJSONToJSON([]byte(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, tt.expect, string(Ugly(res)))
+	}
+}
+
+func TestHJSONErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		line     int
+		column   int
+		expected string
+	}{
+		{"{\n  a: 1\n  b 2\n}", 3, 5, "':'"},
+		{"[1, 2 3]", 1, 9, "']'"},
+		{"[\n  1 2\n  [3] 4\n]", 3, 7, "',' or ']'"},
+		{"{a: [1] b: 2}", 1, 9, "',' or '}'"},
+		{"{\n  a: 1", 2, 7, "'}'"},
+		{"a: '''\n  text", 2, 7, "'''"},
+		{"{,}", 1, 2, "key"},
+		{"a: \"1\" b: 2", 1, 8, "',' or line break"},
+		{"a: [1, ]]", 1, 9, "',' or line break"},
+		{"[1] 2", 1, 5, "end of json"},
+		// an empty document is not an empty object
+		{"", 1, 1, "value"},
+		{" ", 1, 2, "value"},
+		{"# c\n", 2, 1, "value"},
+		// a NUL byte does not end a root object
+		{"a: 1\n\x00", 2, 1, "key"},
+	}
+	for _, tt := range tests {
+		_, err := HJSONToJSON([]byte(tt.src))
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Fatalf("%s: expected *SyntaxError, got %v", tt.src, err)
+		}
+		assertEqual(t, tt.line, serr.Line)
+		assertEqual(t, tt.column, serr.Column)
+		assertEqual(t, tt.expected, serr.Expected)
+	}
+}
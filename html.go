@@ -0,0 +1,189 @@
+package pretty
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HTMLStyle is for HTML. The tokens are wrapped in spans that have the
+// json-key, json-string, json-number, json-true, json-false, json-null,
+// json-escape, json-bracket, and json-match classes, and the <, >, &, and
+// quote characters are written as entities. Use HTMLStylesheet for the CSS.
+var HTMLStyle = markupStyle("span")
+
+// SVGStyle is like HTMLStyle, but using tspan elements for SVG text.
+var SVGStyle = markupStyle("tspan")
+
+func markupStyle(elem string) *Style {
+	class := func(name string) [2]string {
+		return [2]string{"<" + elem + ` class="json-` + name + `">`,
+			"</" + elem + ">"}
+	}
+	return &Style{
+		Key:      class("key"),
+		String:   class("string"),
+		Number:   class("number"),
+		True:     class("true"),
+		False:    class("false"),
+		Null:     class("null"),
+		Escape:   class("escape"),
+		Brackets: class("bracket"),
+		Match:    class("match"),
+		Append:   appendMarkupByte,
+	}
+}
+
+// appendMarkupByte appends the byte with the characters that are special to
+// HTML and XML as entities. The control characters other than tab, line feed,
+// and carriage return are not allowed in XML, so they are written as \u
+// escapes, the same as TerminalStyle does for most of them.
+func appendMarkupByte(dst []byte, c byte) []byte {
+	if c < ' ' && c != '\t' && c != '\n' && c != '\r' {
+		dst = append(dst, "\\u00"...)
+		dst = append(dst, hexp(c>>4))
+		return append(dst, hexp(c&0xF))
+	}
+	switch c {
+	case '<':
+		return append(dst, "&lt;"...)
+	case '>':
+		return append(dst, "&gt;"...)
+	case '&':
+		return append(dst, "&amp;"...)
+	case '"':
+		return append(dst, "&quot;"...)
+	case '\'':
+		return append(dst, "&#39;"...)
+	}
+	return append(dst, c)
+}
+
+// ColorHTML colorizes the json for HTML using the HTMLStyle, in a
+// <pre class="json"> element.
+func ColorHTML(src []byte) []byte {
+	dst := append([]byte{}, `<pre class="json">`...)
+	dst = append(dst, Color(src, HTMLStyle)...)
+	return append(dst, "</pre>"...)
+}
+
+// HTMLStylesheet returns the CSS for the classes of HTMLStyle and
+// ColorHTML, using the colors of one of the themes that are listed in
+// StyleByName, such as "solarized-dark". An empty or unknown theme uses the
+// "github-light" theme.
+func HTMLStylesheet(theme string) []byte {
+	return appendStylesheet(nil, findTheme(theme), "color")
+}
+
+// ColorSVG colorizes the json as an SVG image, with the colors of one of
+// the themes, the same as HTMLStylesheet. The src should be valid json, such
+// as the output of Pretty, because each line of the src is a line of text in
+// the image.
+func ColorSVG(src []byte, theme string) []byte {
+	const (
+		fontSize   = 14
+		lineHeight = 20
+		charWidth  = 0.6 * fontSize
+		pad        = 8
+	)
+	src = bytes.TrimSuffix(src, []byte{'\n'})
+	lines := bytes.Split(src, []byte{'\n'})
+	colored := bytes.Split(Color(src, SVGStyle), []byte{'\n'})
+	var cols int
+	for _, line := range lines {
+		if n := utf8.RuneCount(line); n > cols {
+			cols = n
+		}
+	}
+	t := findTheme(theme)
+	width := strconv.FormatFloat(float64(cols)*charWidth+2*pad, 'f', -1, 64)
+	height := strconv.Itoa(len(lines)*lineHeight + 2*pad)
+	dst := append([]byte{}, `<svg xmlns="http://www.w3.org/2000/svg" width="`...)
+	dst = append(dst, width...)
+	dst = append(dst, `" height="`...)
+	dst = append(dst, height...)
+	dst = append(dst, `" font-family="monospace" font-size="`...)
+	dst = strconv.AppendInt(dst, fontSize, 10)
+	dst = append(dst, "\">\n<style>"...)
+	dst = appendStylesheet(dst, t, "fill")
+	dst = append(dst, "</style>\n<rect width=\"100%\" height=\"100%\" fill=\""...)
+	dst = appendHexColor(dst, t.bg)
+	dst = append(dst, "\"/>\n<text class=\"json\" xml:space=\"preserve\">"...)
+	for i, line := range colored {
+		dst = append(dst, `<tspan x="`...)
+		dst = strconv.AppendInt(dst, pad, 10)
+		dst = append(dst, `" y="`...)
+		dst = strconv.AppendInt(dst, int64(pad+fontSize+i*lineHeight), 10)
+		dst = append(dst, `">`...)
+		dst = append(dst, line...)
+		dst = append(dst, "</tspan>"...)
+	}
+	return append(dst, "</text>\n</svg>\n"...)
+}
+
+// findTheme returns the built-in theme with the name, ignoring the "-256"
+// suffix, or the "github-light" theme.
+func findTheme(name string) theme {
+	name = strings.TrimSuffix(name, "-256")
+	var def theme
+	for _, t := range themes {
+		if t.name == name {
+			return t.theme
+		}
+		if t.name == "github-light" {
+			def = t.theme
+		}
+	}
+	return def
+}
+
+// appendStylesheet appends the CSS rules for the classes of the markup
+// styles. The property is "color" for HTML and "fill" for SVG.
+func appendStylesheet(dst []byte, t theme, property string) []byte {
+	rule := func(class string, rgb uint32, bold bool) {
+		dst = append(dst, '.')
+		dst = append(dst, class...)
+		dst = append(dst, " { "...)
+		dst = append(dst, property...)
+		dst = append(dst, ": "...)
+		dst = appendHexColor(dst, rgb)
+		if bold {
+			dst = append(dst, "; font-weight: bold"...)
+		}
+		if class == "json" && property == "color" {
+			dst = append(dst, "; background: "...)
+			dst = appendHexColor(dst, t.bg)
+		}
+		dst = append(dst, " }\n"...)
+	}
+	rule("json", t.brackets, false)
+	rule("json-key", t.key, t.bold)
+	rule("json-string", t.str, false)
+	rule("json-number", t.num, false)
+	rule("json-true", t.lit, false)
+	rule("json-false", t.lit, false)
+	rule("json-null", t.null, false)
+	rule("json-escape", t.esc, false)
+	rule("json-bracket", t.brackets, t.bold)
+	// the highlights of ColorWithOptions
+	if property == "color" {
+		dst = append(dst, ".json-match { color: "...)
+		dst = appendHexColor(dst, t.bg)
+		dst = append(dst, "; background: "...)
+		dst = appendHexColor(dst, t.key)
+		dst = append(dst, " }\n"...)
+	} else {
+		rule("json-match", t.esc, true)
+	}
+	return dst
+}
+
+// appendHexColor appends the color as #rrggbb.
+func appendHexColor(dst []byte, rgb uint32) []byte {
+	dst = append(dst, '#')
+	for shift := 20; shift >= 0; shift -= 4 {
+		dst = append(dst, hexp(byte(rgb>>uint(shift)&0xF)))
+	}
+	return dst
+}
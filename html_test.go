@@ -0,0 +1,103 @@
+package pretty
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestColorHTML(t *testing.T) {
+	res := string(ColorHTML([]byte(`{"<a>":["x&'y\n",1,null]}`)))
+	expect := `<pre class="json"><span class="json-bracket">{</span>` +
+		`<span class="json-key">&quot;&lt;a&gt;&quot;</span>` +
+		`<span class="json-bracket">:</span>` +
+		`<span class="json-bracket">[</span>` +
+		`<span class="json-string">&quot;x&amp;&#39;y</span>` +
+		`<span class="json-escape">\n</span>` +
+		`<span class="json-string">&quot;</span>,` +
+		`<span class="json-number">1</span>,` +
+		`<span class="json-null">null</span>` +
+		`<span class="json-bracket">]</span>` +
+		`<span class="json-bracket">}</span></pre>`
+	assertEqual(t, expect, res)
+
+	// the entities do not count towards the width
+	json := []byte(`{"a":["<>","&&"]}`)
+	res = string(PrettyOptions(json, &Options{Width: 20, Indent: "  ",
+		Style: HTMLStyle}))
+	if strings.Count(res, "\n") != 3 {
+		t.Fatalf("expected a single line array, got %s", res)
+	}
+}
+
+func TestHTMLStylesheet(t *testing.T) {
+	css := string(HTMLStylesheet("solarized-dark"))
+	if !strings.Contains(css, ".json { color: #93a1a1; background: #002b36 }\n") ||
+		!strings.Contains(css, ".json-key { color: #268bd2 }\n") {
+		t.Fatalf("unexpected stylesheet %s", css)
+	}
+	assertEqual(t, css, string(HTMLStylesheet("solarized-dark-256")))
+	assertEqual(t, string(HTMLStylesheet("github-light")),
+		string(HTMLStylesheet("")))
+	css = string(HTMLStylesheet("high-contrast"))
+	if !strings.Contains(css, ".json-key { color: #56b4e9; font-weight: bold }") {
+		t.Fatalf("unexpected stylesheet %s", css)
+	}
+	css = string(HTMLStylesheet("monokai"))
+	if !strings.Contains(css, ".json-match { color: #272822; background: #66d9ef }\n") {
+		t.Fatalf("unexpected stylesheet %s", css)
+	}
+	res := ColorWithOptions([]byte(`["a<b"]`), &ColorOptions{Style: HTMLStyle,
+		Highlight: &Highlight{Substring: "<"}})
+	expect := `<span class="json-bracket">[</span>` +
+		`<span class="json-string">&quot;a</span>` +
+		`<span class="json-match">&lt;</span>` +
+		`<span class="json-string">b&quot;</span>` +
+		`<span class="json-bracket">]</span>`
+	assertEqual(t, expect, string(res))
+}
+
+func TestColorSVG(t *testing.T) {
+	svg := ColorSVG(Pretty([]byte(`{"a<b":[1,"c&d",true]}`)), "monokai")
+	// the svg is well formed xml with a line of text for each line
+	assertEqual(t, 3, svgLines(t, svg))
+	// the control characters are not allowed in xml
+	ctl := ColorSVG([]byte("[\"a\vb\x01\tc\"]"), "")
+	assertEqual(t, 1, svgLines(t, ctl))
+	if !bytes.Contains(ctl, []byte(`a\u000bb\u0001`+"\t"+`c`)) {
+		t.Fatalf("expected escaped control characters, got %q", ctl)
+	}
+	if !bytes.HasPrefix(svg, []byte(`<svg xmlns="http://www.w3.org/2000/svg" `+
+		`width="226" height="76" `)) {
+		t.Fatalf("unexpected svg %s", svg)
+	}
+	if !bytes.Contains(svg, []byte(`fill="#272822"`)) {
+		t.Fatalf("expected the monokai background, got %s", svg)
+	}
+}
+
+// svgLines returns the number of lines of text in the svg, which must be
+// well formed xml.
+func svgLines(t *testing.T, svg []byte) int {
+	dec := xml.NewDecoder(bytes.NewReader(svg))
+	var lines int
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%v: %s", err, svg)
+		}
+		if el, ok := tok.(xml.StartElement); ok && el.Name.Local == "tspan" {
+			for _, attr := range el.Attr {
+				if attr.Name.Local == "y" {
+					lines++
+				}
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,18 @@
+//go:build linux
+// +build linux
+
+package pretty
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// isatty returns true if the file descriptor is a terminal, which is when
+// it has terminal attributes.
+func isatty(fd uintptr) bool {
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS,
+		uintptr(unsafe.Pointer(&t)))
+	return errno == 0
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package pretty
+
+// isatty returns false because there's no way to tell if the file
+// descriptor is a terminal, and it's better to not write color to a file.
+func isatty(fd uintptr) bool {
+	return false
+}
@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package pretty
+
+import "syscall"
+
+// isatty returns true if the handle is a console.
+func isatty(fd uintptr) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(fd), &mode) == nil
+}
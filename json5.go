@@ -0,0 +1,505 @@
+package pretty
+
+import (
+	"math/big"
+	"unicode"
+	"unicode/utf8"
+)
+
+// JSON5ToJSON converts JSON5, https://spec.json5.org, to JSON. It returns a
+// *SyntaxError when the input is not valid JSON5.
+//
+// Like Spec, the comments and trailing commas are replaced with spaces, and
+// the line breaks are kept on the same lines. The other JSON5 syntax is kept
+// at the same offsets when the JSON is not longer, such as for single quoted
+// strings and hex numbers, which are padded with spaces. Syntax that needs
+// more bytes, such as unquoted keys, moves the offsets that follow it on the
+// same line. The line breaks in multi-line strings are moved to just after
+// the string.
+//
+// The NaN and Infinity numbers are kept as they are. They are not valid
+// JSON, but Pretty and Ugly pass them through.
+func JSON5ToJSON(src []byte) ([]byte, error) {
+	c := json5{src: src, dst: make([]byte, 0, len(src))}
+	i, exp := c.space(0)
+	if exp == "" {
+		if i, exp = c.value(i); exp == "" {
+			if i, exp = c.space(i); exp == "" && i < len(src) {
+				exp = "end of json"
+			}
+		}
+	}
+	if exp != "" {
+		return nil, newSyntaxError(src, i, exp)
+	}
+	return c.dst, nil
+}
+
+type json5 struct {
+	src []byte
+	dst []byte
+	// hash allows the # comments of HJSON
+	hash bool
+	// mapped removes the comments and trailing commas, rather than
+	// replacing them with spaces, and keeps the marks for an OffsetMap
+	mapped bool
+	marks  []offsetMark
+}
+
+// mark records that the next byte of dst is from src[i].
+func (c *json5) mark(i int) {
+	if !c.mapped {
+		return
+	}
+	if n := len(c.marks); n > 0 && c.marks[n-1].dst == len(c.dst) {
+		c.marks = c.marks[:n-1]
+	}
+	c.marks = append(c.marks, offsetMark{len(c.dst), i})
+}
+
+// removeComma removes the trailing comma at dst[i] when mapped, or otherwise
+// replaces it with a space.
+func (c *json5) removeComma(i int) {
+	if !c.mapped {
+		c.dst[i] = ' '
+		return
+	}
+	c.dst = append(c.dst[:i], c.dst[i+1:]...)
+	for j := len(c.marks) - 1; j >= 0 && c.marks[j].dst > i; j-- {
+		c.marks[j].dst--
+	}
+}
+
+// The json5 functions return the index following the syntax, or the index
+// of the error along with a description of what was expected.
+
+// space copies the whitespace and comments, as spaces and line breaks.
+func (c *json5) space(i int) (int, string) {
+	for i < len(c.src) {
+		switch c.src[i] {
+		case ' ', '\t', '\n', '\r':
+			c.dst = append(c.dst, c.src[i])
+			i++
+			continue
+		case '\v', '\f':
+			c.dst = append(c.dst, ' ')
+			i++
+			continue
+		case '#':
+			if !c.hash {
+				return i, ""
+			}
+			for ; i < len(c.src) && c.src[i] != '\n'; i++ {
+				c.appendBlank(c.src[i])
+			}
+			c.mark(i)
+			continue
+		case '/':
+			if i+1 == len(c.src) {
+				return i, ""
+			}
+			switch c.src[i+1] {
+			case '/':
+				for ; i < len(c.src) && c.src[i] != '\n'; i++ {
+					c.appendBlank(c.src[i])
+				}
+				c.mark(i)
+				continue
+			case '*':
+				c.appendBlank(' ')
+				c.appendBlank(' ')
+				for i += 2; ; i++ {
+					if i+1 >= len(c.src) {
+						return len(c.src), "'*/'"
+					}
+					if c.src[i] == '*' && c.src[i+1] == '/' {
+						c.appendBlank(' ')
+						c.appendBlank(' ')
+						i += 2
+						break
+					}
+					c.appendBlank(c.src[i])
+				}
+				c.mark(i)
+				continue
+			}
+			return i, ""
+		}
+		if c.src[i] < utf8.RuneSelf {
+			break
+		}
+		r, n := utf8.DecodeRune(c.src[i:])
+		if r != '\ufeff' && !unicode.Is(unicode.Zs, r) &&
+			r != '\u2028' && r != '\u2029' {
+			break
+		}
+		for j := 0; j < n; j++ {
+			c.dst = append(c.dst, ' ')
+		}
+		i += n
+	}
+	return i, ""
+}
+
+// appendBlank appends the comment byte as a space, keeping the line breaks
+// and tabs. Nothing is appended when mapped.
+func (c *json5) appendBlank(b byte) {
+	if c.mapped {
+		return
+	}
+	if b == '\n' || b == '\r' || b == '\t' {
+		c.dst = append(c.dst, b)
+	} else {
+		c.dst = append(c.dst, ' ')
+	}
+}
+
+func (c *json5) value(i int) (int, string) {
+	if i == len(c.src) {
+		return i, "value"
+	}
+	c.mark(i)
+	switch c.src[i] {
+	case '{':
+		return c.object(i)
+	case '[':
+		return c.array(i)
+	case '"', '\'':
+		return c.string(i)
+	case 't':
+		return c.literal(i, "true")
+	case 'f':
+		return c.literal(i, "false")
+	case 'n':
+		return c.literal(i, "null")
+	case '+', '-', '.', 'I', 'N', '0', '1', '2', '3', '4', '5', '6', '7',
+		'8', '9':
+		return c.number(i)
+	}
+	return i, "value"
+}
+
+func (c *json5) literal(i int, lit string) (int, string) {
+	j, exp := validLiteral(c.src, i, lit)
+	if exp == "" {
+		c.dst = append(c.dst, lit...)
+	}
+	return j, exp
+}
+
+func (c *json5) object(i int) (int, string) {
+	c.dst = append(c.dst, '{')
+	var exp string
+	if i, exp = c.space(i + 1); exp != "" {
+		return i, exp
+	}
+	for {
+		c.mark(i)
+		if i < len(c.src) && c.src[i] == '}' {
+			c.dst = append(c.dst, '}')
+			return i + 1, ""
+		}
+		if i < len(c.src) && (c.src[i] == '"' || c.src[i] == '\'') {
+			i, exp = c.string(i)
+		} else {
+			i, exp = c.identifier(i)
+		}
+		if exp != "" {
+			return i, exp
+		}
+		if i, exp = c.space(i); exp != "" {
+			return i, exp
+		}
+		if i == len(c.src) || c.src[i] != ':' {
+			return i, "':'"
+		}
+		c.mark(i)
+		c.dst = append(c.dst, ':')
+		if i, exp = c.space(i + 1); exp != "" {
+			return i, exp
+		}
+		if i, exp = c.value(i); exp != "" {
+			return i, exp
+		}
+		if i, exp = c.space(i); exp != "" {
+			return i, exp
+		}
+		if i < len(c.src) && c.src[i] == ',' {
+			c.mark(i)
+			comma := len(c.dst)
+			c.dst = append(c.dst, ',')
+			if i, exp = c.space(i + 1); exp != "" {
+				return i, exp
+			}
+			if i < len(c.src) && c.src[i] == '}' {
+				// trailing comma
+				c.removeComma(comma)
+			}
+			continue
+		}
+		if i == len(c.src) || c.src[i] != '}' {
+			return i, "',' or '}'"
+		}
+	}
+}
+
+func (c *json5) array(i int) (int, string) {
+	c.dst = append(c.dst, '[')
+	var exp string
+	if i, exp = c.space(i + 1); exp != "" {
+		return i, exp
+	}
+	for {
+		c.mark(i)
+		if i < len(c.src) && c.src[i] == ']' {
+			c.dst = append(c.dst, ']')
+			return i + 1, ""
+		}
+		if i, exp = c.value(i); exp != "" {
+			return i, exp
+		}
+		if i, exp = c.space(i); exp != "" {
+			return i, exp
+		}
+		if i < len(c.src) && c.src[i] == ',' {
+			c.mark(i)
+			comma := len(c.dst)
+			c.dst = append(c.dst, ',')
+			if i, exp = c.space(i + 1); exp != "" {
+				return i, exp
+			}
+			if i < len(c.src) && c.src[i] == ']' {
+				// trailing comma
+				c.removeComma(comma)
+			}
+			continue
+		}
+		if i == len(c.src) || c.src[i] != ']' {
+			return i, "',' or ']'"
+		}
+	}
+}
+
+// identifier converts an unquoted key to a string.
+func (c *json5) identifier(i int) (int, string) {
+	start := i
+	c.dst = append(c.dst, '"')
+	for i < len(c.src) {
+		if c.src[i] == '\\' {
+			if !isUnicodeEscape(c.src, i) {
+				return i, "identifier"
+			}
+			c.dst = append(c.dst, c.src[i:i+6]...)
+			i += 6
+			continue
+		}
+		r, n := utf8.DecodeRune(c.src[i:])
+		if !isIdentifierRune(r, i > start) {
+			break
+		}
+		c.dst = append(c.dst, c.src[i:i+n]...)
+		i += n
+	}
+	if i == start {
+		return i, "string"
+	}
+	c.dst = append(c.dst, '"')
+	return i, ""
+}
+
+func isIdentifierRune(r rune, part bool) bool {
+	if r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r) {
+		return true
+	}
+	return part && (unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r) ||
+		unicode.Is(unicode.Nd, r) || unicode.Is(unicode.Pc, r) ||
+		r == '\u200c' || r == '\u200d')
+}
+
+// string converts a single or double quoted string. The bytes that are
+// removed, such as line continuations, are added after the string as
+// whitespace.
+func (c *json5) string(i int) (int, string) {
+	quote := c.src[i]
+	c.dst = append(c.dst, '"')
+	var tail []byte
+	for i++; i < len(c.src); {
+		ch := c.src[i]
+		switch {
+		case ch == quote:
+			c.dst = append(c.dst, '"')
+			if !c.mapped {
+				c.dst = append(c.dst, tail...)
+			}
+			return i + 1, ""
+		case ch == '"':
+			c.dst = append(c.dst, '\\', '"')
+			i++
+		case ch == '\n' || ch == '\r':
+			return i, "escaped line break"
+		case ch == '\t':
+			c.dst = append(c.dst, '\\', 't')
+			i++
+		case ch < ' ':
+			c.dst = appendUnicodeEscape(c.dst, rune(ch))
+			i++
+		case ch == '\\':
+			var exp string
+			if i, tail, exp = c.escape(i, tail); exp != "" {
+				return i, exp
+			}
+		default:
+			c.dst = append(c.dst, ch)
+			i++
+		}
+	}
+	return i, "'" + string(quote) + "'"
+}
+
+// escape converts the escape at i to a json escape.
+func (c *json5) escape(i int, tail []byte) (int, []byte, string) {
+	if i+1 == len(c.src) {
+		return i + 1, tail, "escape character"
+	}
+	switch ch := c.src[i+1]; ch {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		c.dst = append(c.dst, '\\', ch)
+	case '\'':
+		c.dst = append(c.dst, '\'')
+		tail = append(tail, ' ')
+	case 'u':
+		if !isUnicodeEscape(c.src, i) {
+			return i + 2, tail, "hex digit"
+		}
+		c.dst = append(c.dst, c.src[i:i+6]...)
+		return i + 6, tail, ""
+	case 'x':
+		if len(c.src)-i < 4 || !isHex(c.src[i+2]) || !isHex(c.src[i+3]) {
+			return i + 2, tail, "hex digit"
+		}
+		c.dst = append(c.dst, '\\', 'u', '0', '0', c.src[i+2], c.src[i+3])
+		return i + 4, tail, ""
+	case 'v':
+		c.dst = append(c.dst, '\\', 'u', '0', '0', '0', 'b')
+	case '0':
+		if i+2 < len(c.src) && isDigit(c.src[i+2]) {
+			return i + 2, tail, "escape character"
+		}
+		c.dst = append(c.dst, '\\', 'u', '0', '0', '0', '0')
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return i + 1, tail, "escape character"
+	case '\n':
+		tail = append(tail, ' ', '\n')
+	case '\r':
+		tail = append(tail, ' ', '\r')
+		if i+2 < len(c.src) && c.src[i+2] == '\n' {
+			tail = append(tail, '\n')
+			return i + 3, tail, ""
+		}
+	default:
+		r, n := utf8.DecodeRune(c.src[i+1:])
+		tail = append(tail, ' ')
+		if r == '\u2028' || r == '\u2029' {
+			// line continuation
+			for j := 0; j < n; j++ {
+				tail = append(tail, ' ')
+			}
+		} else {
+			c.dst = append(c.dst, c.src[i+1:i+1+n]...)
+		}
+		return i + 1 + n, tail, ""
+	}
+	return i + 2, tail, ""
+}
+
+func (c *json5) number(i int) (int, string) {
+	switch c.src[i] {
+	case '+':
+		if !c.mapped {
+			c.dst = append(c.dst, ' ')
+		}
+		i++
+		c.mark(i)
+	case '-':
+		c.dst = append(c.dst, '-')
+		i++
+	}
+	if i == len(c.src) {
+		return i, "digit"
+	}
+	switch c.src[i] {
+	case 'I':
+		return c.literal(i, "Infinity")
+	case 'N':
+		return c.literal(i, "NaN")
+	case '0':
+		if i+1 < len(c.src) && (c.src[i+1] == 'x' || c.src[i+1] == 'X') {
+			return c.hex(i)
+		}
+	}
+	start := i
+	if c.src[i] == '.' {
+		c.dst = append(c.dst, '0')
+	} else if !isDigit(c.src[i]) {
+		return i, "digit"
+	} else if c.src[i] == '0' {
+		c.dst = append(c.dst, '0')
+		i++
+	} else {
+		for ; i < len(c.src) && isDigit(c.src[i]); i++ {
+			c.dst = append(c.dst, c.src[i])
+		}
+	}
+	var pad int
+	if i < len(c.src) && c.src[i] == '.' {
+		i++
+		if i < len(c.src) && isDigit(c.src[i]) {
+			c.dst = append(c.dst, '.')
+			for ; i < len(c.src) && isDigit(c.src[i]); i++ {
+				c.dst = append(c.dst, c.src[i])
+			}
+		} else if i-1 == start {
+			return i, "digit"
+		} else {
+			// trailing decimal point
+			pad++
+		}
+	}
+	if i < len(c.src) && (c.src[i] == 'e' || c.src[i] == 'E') {
+		c.dst = append(c.dst, c.src[i])
+		if i++; i < len(c.src) && (c.src[i] == '+' || c.src[i] == '-') {
+			c.dst = append(c.dst, c.src[i])
+			i++
+		}
+		if i == len(c.src) || !isDigit(c.src[i]) {
+			return i, "digit"
+		}
+		for ; i < len(c.src) && isDigit(c.src[i]); i++ {
+			c.dst = append(c.dst, c.src[i])
+		}
+	}
+	for ; pad > 0 && !c.mapped; pad-- {
+		c.dst = append(c.dst, ' ')
+	}
+	return i, ""
+}
+
+// hex converts a hex number to decimal, padded with spaces when it's
+// shorter.
+func (c *json5) hex(i int) (int, string) {
+	start := i
+	i += 2
+	for ; i < len(c.src) && isHex(c.src[i]); i++ {
+	}
+	if i == start+2 {
+		return i, "hex digit"
+	}
+	var n big.Int
+	n.SetString(string(c.src[start+2:i]), 16)
+	mark := len(c.dst)
+	c.dst = n.Append(c.dst, 10)
+	for j := len(c.dst) - mark; j < i-start && !c.mapped; j++ {
+		c.dst = append(c.dst, ' ')
+	}
+	return i, ""
+}
@@ -0,0 +1,143 @@
+package pretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestJSON5ToJSON(t *testing.T) {
+	// https://json5.org
+	src := `// This file is written in JSON5 syntax
+{
+  // comments
+  unquoted: 'and you can quote me on that',
+  singleQuotes: 'I can use "double quotes" here',
+  lineBreaks: "Look, Mom! \
+No \\n's!",
+  hexadecimal: 0xdecaf,
+  leadingDecimalPoint: .8675309, andTrailing: 8675309.,
+  positiveSign: +1,
+  trailingComma: 'in objects', andIn: ['arrays',],
+  "backwardsCompatible": "with JSON",
+}
+`
+	res, err := JSON5ToJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Valid(res) {
+		t.Fatalf("expected valid json, got %s", res)
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(res, &v); err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"unquoted":            "and you can quote me on that",
+		"singleQuotes":        `I can use "double quotes" here`,
+		"lineBreaks":          `Look, Mom! No \n's!`,
+		"hexadecimal":         float64(0xdecaf),
+		"leadingDecimalPoint": .8675309,
+		"andTrailing":         8675309.,
+		"positiveSign":        1.,
+		"trailingComma":       "in objects",
+		"andIn":               []interface{}{"arrays"},
+		"backwardsCompatible": "with JSON",
+	}
+	for key, val := range expect {
+		assertEqual(t, string(Ugly(mustMarshal(val))),
+			string(Ugly(mustMarshal(v[key]))))
+	}
+	assertEqual(t, len(expect), len(v))
+
+	// the lines are kept
+	for _, key := range []string{"hexadecimal", "andIn", "backwardsCompatible"} {
+		i := bytes.Index([]byte(src), []byte(key))
+		j := bytes.Index(res, []byte(key))
+		assertEqual(t, bytes.Count([]byte(src[:i]), []byte("\n")),
+			bytes.Count(res[:j], []byte("\n")))
+	}
+	assertEqual(t, bytes.Count([]byte(src), []byte("\n")),
+		bytes.Count(res, []byte("\n")))
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestJSON5Offsets(t *testing.T) {
+	// the syntax that's not longer in json keeps the offsets
+	src := "{'a': 0x1F, \"b\": +1, 'c': 5., 'd': 'it\\'s', /* e */ }\t\v"
+	expect := "{\"a\": 31  , \"b\":  1, \"c\": 5 , \"d\": \"it's\"           }\t "
+	res, err := JSON5ToJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, expect, string(res))
+	assertEqual(t, len(src), len(res))
+
+	res, err = JSON5ToJSON([]byte("[NaN, -Infinity, +Infinity, '\\x41\\v\\0\t']"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, `[NaN, -Infinity,  Infinity, "\u0041\u000b\u0000\t"]`, string(res))
+	assertEqual(t, `[NaN,-Infinity,Infinity,"\u0041\u000b\u0000\t"]`,
+		string(Ugly(res)))
+}
+
+func TestJSON5Errors(t *testing.T) {
+	tests := []struct {
+		src      string
+		offset   int
+		expected string
+	}{
+		{``, 0, "value"},
+		{`{a:1,,}`, 5, "string"},
+		{`{a 1}`, 3, "':'"},
+		{`[1 2]`, 3, "',' or ']'"},
+		{`'abc`, 4, `'''`},
+		{"'a\nb'", 2, "escaped line break"},
+		{`"\1"`, 2, "escape character"},
+		{`"\x4"`, 3, "hex digit"},
+		{`/* x`, 4, "'*/'"},
+		{`0x`, 2, "hex digit"},
+		{`[.]`, 2, "digit"},
+		{`[1e]`, 3, "digit"},
+		{`{1:2}`, 1, "string"},
+		{`[1] 2`, 4, "end of json"},
+		{`[1] /`, 4, "end of json"},
+	}
+	for _, tt := range tests {
+		_, err := JSON5ToJSON([]byte(tt.src))
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Fatalf("%s: expected *SyntaxError, got %v", tt.src, err)
+		}
+		assertEqual(t, tt.offset, serr.Offset)
+		assertEqual(t, tt.expected, serr.Expected)
+	}
+}
+
+func TestJSON5Random(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	b := make([]byte, 32)
+	for i := 0; i < 100000; i++ {
+		for j := range b {
+			b[j] = "{}[],:\"'\\ 1.xa/*\n+e"[rand.Intn(19)]
+		}
+		res, err := JSON5ToJSON(b)
+		if Valid(b) && (err != nil || string(res) != string(b)) {
+			t.Fatalf("expected the same json for %s, got %s", b, res)
+		}
+		if err == nil && !Valid(res) {
+			t.Fatalf("expected valid json for %s, got %s", b, res)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package pretty
+
+import (
+	"bytes"
+	"sort"
+)
+
+// PrettyJSONC is like PrettyOptions but for JSON with comments, such as a
+// tsconfig.json or a VS Code settings file, and it keeps the comments. A
+// comment that's on the line before a member stays above it, and a comment
+// that follows a member on the same line stays after it, including when
+// SortKeys reorders the members. The arrays and objects that have comments
+// are always on multiple lines. The SortArrays option is not used. Trailing
+// commas are removed, unless the TrailingCommas option is set.
+//
+// When the input is not valid JSON with comments it's formatted like
+// PrettyOptions(Spec(src), opts), which loses the comments.
+func PrettyJSONC(src []byte, opts *Options) []byte {
+	if opts == nil {
+		opts = DefaultOptions
+	}
+	if spec := Spec(src); !Valid(spec) {
+		return PrettyOptions(spec, opts)
+	}
+	c := jsonc{src: src}
+	var root jsoncNode
+	i, cs := c.space(0)
+	root.before = commentText(cs)
+	i = c.value(i, &root)
+	_, cs = c.space(i)
+	var trail [][]byte
+	root.after, trail = splitComments(cs)
+
+	buf := make([]byte, 0, len(src))
+	if len(opts.Prefix) != 0 {
+		buf = append(buf, opts.Prefix...)
+	}
+	nl := 0
+	for _, text := range root.before {
+		buf = append(buf, text...)
+		buf, nl = appendNewline(buf, opts, 0)
+	}
+	buf, nl = appendJSONCNode(buf, &root, opts, 0, nl)
+	buf = appendComments(buf, root.after)
+	for _, text := range trail {
+		buf, _ = appendNewline(buf, opts, 0)
+		buf = append(buf, text...)
+	}
+	return append(buf, '\n')
+}
+
+// jsoncNode is a value along with its comments.
+type jsoncNode struct {
+	key    []byte       // the key when it's an object member
+	raw    []byte       // a string, number, or literal
+	open   byte         // '{' or '[' when it's an object or array
+	elems  []*jsoncNode // the members or elements
+	before [][]byte     // the comments on the lines before the value
+	after  [][]byte     // the comments that follow the value on the same line
+	head   [][]byte     // the comments that follow the open bracket
+	tail   [][]byte     // the comments before the close bracket
+}
+
+// jsoncComment is a comment, and whether there's a line break between the
+// comment and the previous token.
+type jsoncComment struct {
+	text    []byte
+	newline bool
+}
+
+// jsonc parses JSON with comments that is known to be valid, such that the
+// input passes Valid(Spec(src)).
+type jsonc struct {
+	src []byte
+}
+
+// space returns the index following the space and comments at i, along with
+// the comments.
+func (c *jsonc) space(i int) (int, []jsoncComment) {
+	var cs []jsoncComment
+	var newline bool
+	for i < len(c.src) {
+		switch c.src[i] {
+		case '\n':
+			newline = true
+			i++
+		case ' ', '\t', '\r':
+			i++
+		case '/':
+			s := i
+			if i+1 < len(c.src) && c.src[i+1] == '/' {
+				for ; i < len(c.src) && c.src[i] != '\n'; i++ {
+				}
+			} else {
+				i += 2
+				for ; i < len(c.src) && !bytes.HasPrefix(c.src[i:], []byte("*/")); i++ {
+				}
+				if i < len(c.src) {
+					i += 2
+				} else {
+					// unterminated, which Spec allows at the end
+					i = len(c.src)
+				}
+			}
+			text := bytes.TrimRight(c.src[s:i], " \t\r")
+			cs = append(cs, jsoncComment{text, newline})
+		default:
+			return i, cs
+		}
+	}
+	return i, cs
+}
+
+// value parses the value at i into the node, and returns the index following
+// the value.
+func (c *jsonc) value(i int, n *jsoncNode) int {
+	switch c.src[i] {
+	case '{', '[':
+		return c.members(i, n)
+	case '"':
+		j := stringEnd(c.src, i)
+		n.raw = c.src[i:j]
+		return j
+	}
+	j := i
+	for ; j < len(c.src); j++ {
+		ch := c.src[j]
+		if ch <= ' ' || ch == ',' || ch == ']' || ch == '}' || ch == '/' {
+			break
+		}
+	}
+	n.raw = c.src[i:j]
+	return j
+}
+
+// members parses the object or array at i.
+func (c *jsonc) members(i int, n *jsoncNode) int {
+	n.open = c.src[i]
+	close := byte(']')
+	if n.open == '{' {
+		close = '}'
+	}
+	i, cs := c.space(i + 1)
+	var pending [][]byte
+	n.head, pending = splitComments(cs)
+	for c.src[i] != close {
+		m := &jsoncNode{before: pending}
+		if n.open == '{' {
+			j := stringEnd(c.src, i)
+			m.key = c.src[i:j]
+			// the comments around the colon are moved above the member
+			i, cs = c.space(j)
+			m.before = append(m.before, commentText(cs)...)
+			i, cs = c.space(i + 1)
+			m.before = append(m.before, commentText(cs)...)
+		}
+		i = c.value(i, m)
+		i, cs = c.space(i)
+		m.after, pending = splitComments(cs)
+		if c.src[i] == ',' {
+			i, cs = c.space(i + 1)
+			after, next := splitComments(cs)
+			if len(pending) == 0 {
+				m.after = append(m.after, after...)
+			} else {
+				pending = append(pending, after...)
+			}
+			pending = append(pending, next...)
+		}
+		n.elems = append(n.elems, m)
+	}
+	n.tail = pending
+	return i + 1
+}
+
+// splitComments splits the comments into the ones that are on the same line
+// as the previous token, and the ones that come after a line break.
+func splitComments(cs []jsoncComment) (same, next [][]byte) {
+	for _, c := range cs {
+		if c.newline || len(next) > 0 {
+			next = append(next, c.text)
+		} else {
+			same = append(same, c.text)
+		}
+	}
+	return same, next
+}
+
+func commentText(cs []jsoncComment) [][]byte {
+	var texts [][]byte
+	for _, c := range cs {
+		texts = append(texts, c.text)
+	}
+	return texts
+}
+
+// hasComments returns true if there are comments inside of the value.
+func (n *jsoncNode) hasComments() bool {
+	if len(n.head) > 0 || len(n.tail) > 0 {
+		return true
+	}
+	for _, m := range n.elems {
+		if len(m.before) > 0 || len(m.after) > 0 || m.hasComments() {
+			return true
+		}
+	}
+	return false
+}
+
+// appendJSON appends the value as compacted json, without the comments.
+func (n *jsoncNode) appendJSON(buf []byte) []byte {
+	if n.open == 0 {
+		return append(buf, n.raw...)
+	}
+	buf = append(buf, n.open)
+	for i, m := range n.elems {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		if m.key != nil {
+			buf = append(buf, m.key...)
+			buf = append(buf, ':')
+		}
+		buf = m.appendJSON(buf)
+	}
+	if n.open == '{' {
+		return append(buf, '}')
+	}
+	return append(buf, ']')
+}
+
+// appendJSONCNode appends the value. The values that do not have comments
+// inside of them are formatted the same as PrettyOptions.
+func appendJSONCNode(buf []byte, n *jsoncNode, opts *Options, tabs, nl int) ([]byte, int) {
+	if !n.hasComments() {
+		json := n.appendJSON(nil)
+		buf, _, nl, _ = appendPrettyAny(buf, json, 0, true, opts, &state{}, tabs, nl, -1)
+		return buf, nl
+	}
+	style := opts.Style
+	buf, nl = appendPunct(buf, n.open, true, style, tabs, nl)
+	buf = appendComments(buf, n.head)
+	elems := n.elems
+	if n.open == '{' {
+		elems = jsoncMembers(elems, opts)
+	}
+	for i, m := range elems {
+		for _, text := range m.before {
+			buf, nl = appendNewline(buf, opts, tabs+1)
+			buf = append(buf, text...)
+		}
+		buf, nl = appendNewline(buf, opts, tabs+1)
+		if m.key != nil {
+			buf, _, nl, _ = appendPrettyString(buf, m.key, 0, true, opts, nl)
+			buf, nl = appendPunct(buf, ':', true, style, tabs, nl)
+			buf = append(buf, ' ')
+		}
+		buf, nl = appendJSONCNode(buf, m, opts, tabs+1, nl)
+		if i < len(elems)-1 || opts.TrailingCommas {
+			buf, nl = appendPunct(buf, ',', n.open == '{', style, tabs, nl)
+		}
+		buf = appendComments(buf, m.after)
+	}
+	for _, text := range n.tail {
+		buf, nl = appendNewline(buf, opts, tabs+1)
+		buf = append(buf, text...)
+	}
+	buf, nl = appendNewline(buf, opts, tabs)
+	if n.open == '{' {
+		return appendPunct(buf, '}', true, style, tabs, nl)
+	}
+	return appendPunct(buf, ']', true, style, tabs, nl)
+}
+
+// jsoncMembers returns the members of an object with the OmitNulls,
+// DuplicateKeys, and SortKeys options applied.
+func jsoncMembers(elems []*jsoncNode, opts *Options) []*jsoncNode {
+	if opts.OmitNulls {
+		var kept []*jsoncNode
+		for _, m := range elems {
+			if string(m.raw) != "null" {
+				kept = append(kept, m)
+			}
+		}
+		elems = kept
+	}
+	if !opts.SortKeys && opts.DuplicateKeys != DuplicateKeepFirst &&
+		opts.DuplicateKeys != DuplicateKeepLast {
+		return elems
+	}
+	// the pairs are for the compacted members, with the vstart being the
+	// index of the member
+	var json []byte
+	pairs := make([]pair, len(elems))
+	for i, m := range elems {
+		pairs[i].vstart = i
+		pairs[i].kstart = len(json)
+		json = append(json, m.key...)
+		pairs[i].kend = len(json)
+		json = m.appendJSON(json)
+		pairs[i].jend = len(json)
+	}
+	if opts.DuplicateKeys == DuplicateKeepFirst ||
+		opts.DuplicateKeys == DuplicateKeepLast {
+		pairs = uniquePairs(json, pairs, opts.DuplicateKeys == DuplicateKeepLast)
+	}
+	if opts.SortKeys {
+		sort.Stable(&byKeyVal{false, json, pairs, opts})
+	}
+	sorted := make([]*jsoncNode, len(pairs))
+	for i, p := range pairs {
+		sorted[i] = elems[p.vstart]
+	}
+	return sorted
+}
+
+// appendNewline appends a line break and the indentation, and returns the
+// start of the new line.
+func appendNewline(buf []byte, opts *Options, tabs int) ([]byte, int) {
+	nl := len(buf)
+	buf = append(buf, '\n')
+	return appendTabs(buf, opts.Prefix, opts.Indent, tabs), nl
+}
+
+// appendComments appends the comments that follow a token on the same line.
+func appendComments(buf []byte, texts [][]byte) []byte {
+	for _, text := range texts {
+		buf = append(buf, ' ')
+		buf = append(buf, text...)
+	}
+	return buf
+}
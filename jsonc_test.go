@@ -0,0 +1,98 @@
+package pretty
+
+import "testing"
+
+func TestPrettyJSONC(t *testing.T) {
+	src := `// tsconfig
+{
+  "compilerOptions": { // the compiler
+    /* the output */
+    "target":"es2020", "module" : "commonjs", // modules
+    "strict": true,
+    "lib": ["dom", "es2020"],
+  },
+  "include": ["src"] /* sources */ ,
+  // the end
+}
+`
+	expect := `// tsconfig
+{
+  "compilerOptions": { // the compiler
+    /* the output */
+    "target": "es2020",
+    "module": "commonjs", // modules
+    "strict": true,
+    "lib": ["dom", "es2020"]
+  },
+  "include": ["src"] /* sources */
+  // the end
+}
+`
+	assertEqual(t, expect, string(PrettyJSONC([]byte(src), nil)))
+
+	// the comments move with the members
+	expect = `// tsconfig
+{
+  "compilerOptions": { // the compiler
+    "lib": ["dom", "es2020"],
+    "module": "commonjs", // modules
+    "strict": true,
+    /* the output */
+    "target": "es2020"
+  },
+  "include": ["src"] /* sources */
+  // the end
+}
+`
+	opts := *DefaultOptions
+	opts.SortKeys = true
+	assertEqual(t, expect, string(PrettyJSONC([]byte(src), &opts)))
+}
+
+func TestPrettyJSONCLayout(t *testing.T) {
+	// values without comments use the same layout as PrettyOptions
+	src := `{"a":[1,2,3],"b":{"c":null},"d":[]}`
+	assertEqual(t, string(Pretty([]byte(src))),
+		string(PrettyJSONC([]byte(src), nil)))
+
+	src = `[1, // one
+2 /* two */, 3]`
+	expect := "[\n  1, // one\n  2, /* two */\n  3\n]\n"
+	assertEqual(t, expect, string(PrettyJSONC([]byte(src), nil)))
+
+	src = "{\n  // nothing\n}"
+	expect = "{\n\t// nothing\n}\n"
+	assertEqual(t, expect, string(PrettyJSONC([]byte(src), &Options{Indent: "\t"})))
+
+	src = `{"b": 1, // one
+"a": null, "b": 2 // two
+}`
+	expect = "{\n  \"b\": 2 // two\n}\n"
+	assertEqual(t, expect, string(PrettyJSONC([]byte(src), &Options{
+		Indent: "  ", OmitNulls: true, DuplicateKeys: DuplicateKeepLast,
+	})))
+
+	src = "\"a\" // b\n// c\n"
+	expect = "\"a\" // b\n// c\n"
+	assertEqual(t, expect, string(PrettyJSONC([]byte(src), nil)))
+
+	src = "{\"a\":1, // one\n\"b\":[2]}"
+	expect = "{\n  a: 1, // one\n  b: [2],\n}\n"
+	assertEqual(t, expect, string(PrettyJSONC([]byte(src), &Options{
+		Width: 80, Indent: "  ", TrailingCommas: true, JSON5: true,
+	})))
+
+	// an unterminated comment at the end is kept as it is
+	src = `{"a":1}/*`
+	expect = "{\n  \"a\": 1\n} /*\n"
+	assertEqual(t, expect, string(PrettyJSONC([]byte(src), nil)))
+	src = "[1] /* two"
+	expect = "[1] /* two\n"
+	assertEqual(t, expect, string(PrettyJSONC([]byte(src), nil)))
+
+	// invalid input loses the comments
+	src = `{"a": 1 // one
+"b": 2}`
+	assertEqual(t, string(PrettyOptions(Spec([]byte(src)), nil)),
+		string(PrettyJSONC([]byte(src), nil)))
+}
@@ -0,0 +1,149 @@
+package pretty
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// NumberMode is how numbers are written
+type NumberMode int
+
+const (
+	// NumbersVerbatim keeps the numbers as they are in the input
+	NumbersVerbatim NumberMode = iota
+	// NumbersShortest writes the shortest number that parses back to the
+	// same float64, in the same form as JavaScript. For example, 1.0, 1e0,
+	// and 10e-1 all become 1.
+	NumbersShortest
+	// NumbersInteger writes the numbers that are integers with all of their
+	// digits, such as 12345678901234567890, and the others the same as
+	// NumbersShortest
+	NumbersInteger
+	// NumbersFixed writes the numbers with NumberDecimals decimal places,
+	// rounding half away from zero
+	NumbersFixed
+)
+
+// maxNumberExp is the largest exponent that NumbersInteger and NumbersFixed
+// expand into digits. Larger numbers are written the same as
+// NumbersShortest.
+const maxNumberExp = 400
+
+// appendNumber appends the number in the form of the Numbers option. Numbers
+// that are not valid json, such as NaN, or that are out of the range of a
+// float64 are kept as they are.
+func appendNumber(buf, num []byte, opts *Options) []byte {
+	if j, exp := validNumber(num, 0); exp != "" || j != len(num) {
+		return append(buf, num...)
+	}
+	if opts.Numbers != NumbersShortest {
+		exp := numberExp(num)
+		if opts.Numbers == NumbersFixed && exp <= -maxNumberExp {
+			return appendRat(buf, new(big.Rat), opts.NumberDecimals)
+		}
+		if exp > -maxNumberExp && exp < maxNumberExp {
+			var r big.Rat
+			r.SetString(string(num))
+			if opts.Numbers == NumbersFixed {
+				return appendRat(buf, &r, opts.NumberDecimals)
+			}
+			if r.IsInt() {
+				return appendRat(buf, &r, 0)
+			}
+		}
+	}
+	f, err := strconv.ParseFloat(string(num), 64)
+	if err != nil {
+		return append(buf, num...)
+	}
+	return appendESNumber(buf, f)
+}
+
+// numberExp returns the exponent of the number, which is after the 'e'.
+func numberExp(num []byte) int {
+	for i, c := range num {
+		if c == 'e' || c == 'E' {
+			exp, err := strconv.Atoi(string(num[i+1:]))
+			if err != nil {
+				// too many digits
+				if num[i+1] == '-' {
+					return -maxNumberExp
+				}
+				return maxNumberExp
+			}
+			return exp
+		}
+	}
+	return 0
+}
+
+// appendRat appends the number with the decimal places, without a negative
+// zero.
+func appendRat(buf []byte, r *big.Rat, decimals int) []byte {
+	if decimals < 0 {
+		decimals = 0
+	}
+	s := r.FloatString(decimals)
+	if s[0] == '-' && strings.Trim(s[1:], "0.") == "" {
+		s = s[1:]
+	}
+	return append(buf, s...)
+}
+
+// appendESNumber appends the number in the form of the ECMAScript
+// Number.prototype.toString function.
+func appendESNumber(buf []byte, f float64) []byte {
+	if f == 0 {
+		// includes negative zero
+		return append(buf, '0')
+	}
+	if f < 0 {
+		buf = append(buf, '-')
+		f = -f
+	}
+	// the shortest digits that round trip, in the d.ddde±x form
+	var scratch, dscratch [32]byte
+	e := strconv.AppendFloat(scratch[:0], f, 'e', -1, 64)
+	digits := dscratch[:0]
+	var exp int
+	for i, c := range e {
+		if c == 'e' {
+			exp, _ = strconv.Atoi(string(e[i+1:]))
+			break
+		}
+		if c != '.' {
+			digits = append(digits, c)
+		}
+	}
+	k, n := len(digits), exp+1
+	switch {
+	case k <= n && n <= 21:
+		buf = append(buf, digits...)
+		for i := k; i < n; i++ {
+			buf = append(buf, '0')
+		}
+	case 0 < n && n <= 21:
+		buf = append(buf, digits[:n]...)
+		buf = append(buf, '.')
+		buf = append(buf, digits[n:]...)
+	case -6 < n && n <= 0:
+		buf = append(buf, '0', '.')
+		for i := n; i < 0; i++ {
+			buf = append(buf, '0')
+		}
+		buf = append(buf, digits...)
+	default:
+		buf = append(buf, digits[0])
+		if k > 1 {
+			buf = append(buf, '.')
+			buf = append(buf, digits[1:]...)
+		}
+		buf = append(buf, 'e')
+		if n-1 >= 0 {
+			buf = append(buf, '+')
+		}
+		buf = strconv.AppendInt(buf, int64(n-1), 10)
+	}
+	return buf
+}
@@ -0,0 +1,41 @@
+package pretty
+
+import "testing"
+
+func TestNumbers(t *testing.T) {
+	json := `[1.0,1e0,1E+00,10e-1,-0,0.1,1e21,1e-7,12345678901234567890,` +
+		`1.25e2,2.345,-0.001,1e400,NaN,-Inf]`
+	tests := []struct {
+		mode     NumberMode
+		decimals int
+		expect   string
+	}{
+		{NumbersVerbatim, 0, json},
+		{NumbersShortest, 0, `[1,1,1,1,0,0.1,1e+21,1e-7,12345678901234567000,` +
+			`125,2.345,-0.001,1e400,NaN,-Inf]`},
+		{NumbersInteger, 0, `[1,1,1,1,0,0.1,1000000000000000000000,1e-7,` +
+			`12345678901234567890,125,2.345,-0.001,1e400,NaN,-Inf]`},
+		{NumbersFixed, 2, `[1.00,1.00,1.00,1.00,0.00,0.10,` +
+			`1000000000000000000000.00,0.00,12345678901234567890.00,125.00,` +
+			`2.35,0.00,1e400,NaN,-Inf]`},
+		{NumbersFixed, 0, `[1,1,1,1,0,0,1000000000000000000000,0,` +
+			`12345678901234567890,125,2,0,1e400,NaN,-Inf]`},
+	}
+	for _, tt := range tests {
+		opts := Options{Numbers: tt.mode, NumberDecimals: tt.decimals}
+		res := string(UglyOptions([]byte(json), &opts))
+		if res != tt.expect {
+			t.Fatalf("mode %d: expected '%s', got '%s'", tt.mode, tt.expect, res)
+		}
+	}
+	res := string(UglyOptions([]byte(`[1e-1000000000,1e999999999999999999999]`),
+		&Options{Numbers: NumbersFixed, NumberDecimals: 1}))
+	assertEqual(t, `[0.0,1e999999999999999999999]`, res)
+
+	// the styled output is the same as coloring the plain output
+	opts := Options{Width: 80, Indent: "  ", Numbers: NumbersShortest,
+		Style: TerminalStyle}
+	res = string(PrettyOptions([]byte(json), &opts))
+	opts.Style = nil
+	assertEqual(t, string(Color(PrettyOptions([]byte(json), &opts), nil)), res)
+}
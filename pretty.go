@@ -21,8 +21,85 @@ type Options struct {
 	// SortKeys will sort the keys alphabetically
 	// Default is false
 	SortKeys bool
+	// DuplicateKeys is the policy for objects that have the same key more
+	// than once
+	// Default is DuplicateKeepAll
+	DuplicateKeys DuplicatePolicy
+	// CompactObjects will put objects on a single line when they fit in
+	// Width, the same as arrays
+	// Default is false
+	CompactObjects bool
+	// ArrayWrap will put as many strings, numbers, and literals on each line
+	// as fit in Width, when an array does not fit on a single line
+	// Default is false
+	ArrayWrap bool
+	// Style will colorize the output, the same as Color does
+	// Default is nil, which is no color
+	Style *Style
+	// KeyOrder is a list of keys that SortKeys puts first, in the order
+	// given, followed by the rest of the keys
+	// Default is nil
+	KeyOrder []string
+	// KeyLess is the comparison used by SortKeys. The params are the keys
+	// with the quotes removed and escapes decoded
+	// Default is nil, which compares the keys using SortMode
+	KeyLess func(a, b []byte) bool
+	// SortMode is how SortKeys compares keys and string values
+	// Default is SortBytes
+	SortMode SortMode
+	// SortArrays will sort the elements of arrays, comparing them the same
+	// way that SortKeys compares values. Arrays and objects are compared
+	// element by element.
+	// Default is false
+	SortArrays bool
+	// SortArrayPaths limits SortArrays to the arrays at the paths, such as
+	// "tags" or "users.#.roles". A "*" matches any key or element, a "#"
+	// matches any element, and an empty path is the root array.
+	// Default is nil, which is all arrays
+	SortArrayPaths []string
+	// OmitNulls will remove the object members that have a null value
+	// Default is false
+	OmitNulls bool
+	// Escapes is how the strings are escaped
+	// Default is EscapeVerbatim
+	Escapes Escapes
+	// Numbers is how the numbers are written
+	// Default is NumbersVerbatim
+	Numbers NumberMode
+	// NumberDecimals is the number of decimal places for NumbersFixed
+	// Default is zero
+	NumberDecimals int
+	// InvalidUTF8 is the policy for strings that have invalid UTF-8 bytes, or
+	// escapes of lone surrogates
+	// Default is UTF8Pass
+	InvalidUTF8 UTF8Policy
+	// TrailingCommas will add a comma after the last member of the arrays
+	// and objects that are on multiple lines, as allowed by JSONC and JSON5
+	// Default is false
+	TrailingCommas bool
+	// JSON5 will write the object keys that are identifiers without quotes,
+	// and use single quotes for the strings that have more double quotes
+	// than single quotes, which makes the output JSON5 rather than JSON
+	// Default is false
+	JSON5 bool
 }
 
+// DuplicatePolicy is what to do with duplicate keys in an object
+type DuplicatePolicy int
+
+const (
+	// DuplicateKeepAll keeps every member of the object
+	DuplicateKeepAll DuplicatePolicy = iota
+	// DuplicateKeepFirst keeps only the first member that has a given key
+	DuplicateKeepFirst
+	// DuplicateKeepLast keeps only the last member that has a given key
+	DuplicateKeepLast
+	// DuplicateError causes PrettyE, UglyE, and ColorE to fail with a
+	// *SyntaxError. The functions that can't return an error keep every
+	// member.
+	DuplicateError
+)
+
 // DefaultOptions is the default options for pretty formats.
 var DefaultOptions = &Options{Width: 80, Prefix: "", Indent: "  ", SortKeys: false}
 
@@ -39,15 +116,27 @@ func PrettyOptions(json []byte, opts *Options) []byte {
 	if len(opts.Prefix) != 0 {
 		buf = append(buf, opts.Prefix...)
 	}
-	buf, _, _, _ = appendPrettyAny(buf, json, 0, true,
-		opts.Width, opts.Prefix, opts.Indent, opts.SortKeys,
-		0, 0, -1)
+	st := state{sorter: opts.arraySorter()}
+	buf, _, _, _ = appendPrettyAny(buf, json, 0, true, opts, &st, 0, 0, -1)
 	if len(buf) > 0 {
 		buf = append(buf, '\n')
 	}
 	return buf
 }
 
+// PrettyE is like PrettyOptions but it returns a *SyntaxError, and no
+// output, when the input is not valid json. The input is checked in its own
+// pass before it's formatted, which costs the same as calling Valid and then
+// PrettyOptions. The check takes about a third of the time of the formatting,
+// and it does not allocate, unless DuplicateKeys is DuplicateError, which
+// keeps the keys of each object in a map.
+func PrettyE(json []byte, opts *Options) ([]byte, error) {
+	if err := opts.validator().validate(json); err != nil {
+		return nil, err
+	}
+	return PrettyOptions(json, opts), nil
+}
+
 // Ugly removes insignificant space characters from the input json byte slice
 // and returns the compacted result.
 func Ugly(json []byte) []byte {
@@ -55,6 +144,30 @@ func Ugly(json []byte) []byte {
 	return ugly(buf, json)
 }
 
+// UglyOptions is like Ugly but with customized options. The Width, Prefix,
+// and Indent options are not used.
+func UglyOptions(json []byte, opts *Options) []byte {
+	if opts == nil {
+		return Ugly(json)
+	}
+	copts := *opts
+	copts.Width = -1
+	buf := make([]byte, 0, len(json))
+	st := state{sorter: opts.arraySorter()}
+	buf, _, _, _ = appendPrettyAny(buf, json, 0, false, &copts, &st, 0, 0, -1)
+	return buf
+}
+
+// UglyE is like UglyOptions but it returns a *SyntaxError, and no output,
+// when the input is not valid json. Like PrettyE it reads the input twice,
+// and here the check takes longer than Ugly itself.
+func UglyE(json []byte, opts *Options) ([]byte, error) {
+	if err := opts.validator().validate(json); err != nil {
+		return nil, err
+	}
+	return UglyOptions(json, opts), nil
+}
+
 // UglyInPlace removes insignificant space characters from the input json
 // byte slice and returns the compacted result. This method reuses the
 // input json buffer to avoid allocations. Do not use the original bytes
@@ -87,6 +200,24 @@ func ugly(dst, src []byte) []byte {
 	return dst
 }
 
+// validator returns the validator used by the functions that return an
+// error.
+func (opts *Options) validator() *validator {
+	if opts == nil {
+		return &validator{}
+	}
+	return newValidator(opts.DuplicateKeys, opts.InvalidUTF8)
+}
+
+// newValidator returns a validator for the policies.
+func newValidator(duplicates DuplicatePolicy, invalid UTF8Policy) *validator {
+	v := &validator{}
+	v.DisallowDuplicateKeys = duplicates == DuplicateError
+	v.AllowInvalidUTF8 = invalid == UTF8Replace || invalid == UTF8Escape
+	v.surrogates = invalid == UTF8Error
+	return v
+}
+
 func isNaNOrInf(src []byte) bool {
 	return src[0] == 'i' || //Inf
 		src[0] == 'I' || // inf
@@ -95,46 +226,122 @@ func isNaNOrInf(src []byte) bool {
 		(src[0] == 'n' && len(src) > 1 && src[1] != 'u') // nan
 }
 
-func appendPrettyAny(buf, json []byte, i int, pretty bool, width int, prefix, indent string, sortkeys bool, tabs, nl, max int) ([]byte, int, int, bool) {
+// state is what the formatter keeps for the whole of one call.
+type state struct {
+	// sorter sorts the arrays, and has the path to the value that's being
+	// formatted, when SortArrays is set.
+	sorter *arraySorter
+	// sorted is true while formatting the elements of a sorted array.
+	sorted bool
+}
+
+func appendPrettyAny(buf, json []byte, i int, pretty bool, opts *Options, st *state, tabs, nl, max int) ([]byte, int, int, bool) {
 	for ; i < len(json); i++ {
 		if json[i] <= ' ' {
 			continue
 		}
 		if json[i] == '"' {
-			return appendPrettyString(buf, json, i, nl)
+			return appendPrettyString(buf, json, i, false, opts, nl)
 		}
 
 		if (json[i] >= '0' && json[i] <= '9') || json[i] == '-' || isNaNOrInf(json[i:]) {
-			return appendPrettyNumber(buf, json, i, nl)
+			return appendPrettyNumber(buf, json, i, opts, nl)
 		}
 		if json[i] == '{' {
-			return appendPrettyObject(buf, json, i, '{', '}', pretty, width, prefix, indent, sortkeys, tabs, nl, max)
+			return appendPrettyObject(buf, json, i, '{', '}', pretty, opts, st, tabs, nl, max)
 		}
 		if json[i] == '[' {
-			return appendPrettyObject(buf, json, i, '[', ']', pretty, width, prefix, indent, sortkeys, tabs, nl, max)
+			return appendPrettyObject(buf, json, i, '[', ']', pretty, opts, st, tabs, nl, max)
 		}
 		switch json[i] {
 		case 't':
+			if opts.Style != nil {
+				buf, nl = appendStyle(buf, litTrue, opts.Style.True, opts.Style, nl)
+				return buf, i + 4, nl, true
+			}
 			return append(buf, 't', 'r', 'u', 'e'), i + 4, nl, true
 		case 'f':
+			if opts.Style != nil {
+				buf, nl = appendStyle(buf, litFalse, opts.Style.False, opts.Style, nl)
+				return buf, i + 5, nl, true
+			}
 			return append(buf, 'f', 'a', 'l', 's', 'e'), i + 5, nl, true
 		case 'n':
+			if opts.Style != nil {
+				buf, nl = appendStyle(buf, litNull, opts.Style.Null, opts.Style, nl)
+				return buf, i + 4, nl, true
+			}
 			return append(buf, 'n', 'u', 'l', 'l'), i + 4, nl, true
 		}
 	}
 	return buf, i, nl, true
 }
 
+var (
+	litTrue  = []byte("true")
+	litFalse = []byte("false")
+	litNull  = []byte("null")
+	litPunct = []byte("{}[]:,")
+)
+
+// appendStyle appends the token wrapped in the color. The nl is moved
+// forward by the number of bytes that are added to the plain token, which
+// keeps the column calculations, such as "len(buf) - nl", counting only the
+// characters that are seen on the screen.
+func appendStyle(buf, tok []byte, color [2]string, style *Style, nl int) ([]byte, int) {
+	if style == nil {
+		buf = append(buf, tok...)
+	} else {
+		buf, nl = appendStyled(buf, tok, color, style, nl)
+	}
+	return buf, nl
+}
+
+func appendStyled(buf, tok []byte, color [2]string, style *Style, nl int) ([]byte, int) {
+	mark := len(buf)
+	buf = append(buf, color[0]...)
+	if style.Append == nil {
+		buf = append(buf, tok...)
+	} else {
+		for _, c := range tok {
+			buf = style.Append(buf, c)
+		}
+	}
+	buf = append(buf, color[1]...)
+	return buf, nl + (len(buf) - mark - len(tok))
+}
+
+// appendPunct appends one of the {}[]:, characters, which are colored
+// with the brackets style of the depth when they are not an array comma.
+// The depth is of the array or object that the character belongs to.
+func appendPunct(buf []byte, c byte, brackets bool, style *Style, depth, nl int) ([]byte, int) {
+	if style == nil {
+		buf = append(buf, c)
+	} else {
+		buf, nl = appendStyledPunct(buf, c, brackets, style, depth, nl)
+	}
+	return buf, nl
+}
+
+func appendStyledPunct(buf []byte, c byte, brackets bool, style *Style, depth, nl int) ([]byte, int) {
+	i := bytes.IndexByte(litPunct, c)
+	if !brackets {
+		return appendStyled(buf, litPunct[i:i+1], [2]string{}, style, nl)
+	}
+	return appendStyled(buf, litPunct[i:i+1], style.brackets(depth), style, nl)
+}
+
 type pair struct {
-	kstart, kend int
-	vstart, vend int
+	kstart, kend int // the key in the json
+	vstart, vend int // the member in the output
+	jend         int // the value in the json, which follows the key
 }
 
 type byKeyVal struct {
 	sorted bool
 	json   []byte
-	buf    []byte
 	pairs  []pair
+	opts   *Options
 }
 
 func (arr *byKeyVal) Len() int {
@@ -197,17 +404,14 @@ func (arr *byKeyVal) isLess(i, j int, kind byKind) bool {
 	k2 := arr.json[arr.pairs[j].kstart:arr.pairs[j].kend]
 	var v1, v2 []byte
 	if kind == byKey {
+		if len(arr.opts.KeyOrder) > 0 || arr.opts.KeyLess != nil {
+			return arr.keyLess(parsestr(k1), parsestr(k2))
+		}
 		v1 = k1
 		v2 = k2
 	} else {
-		v1 = bytes.TrimSpace(arr.buf[arr.pairs[i].vstart:arr.pairs[i].vend])
-		v2 = bytes.TrimSpace(arr.buf[arr.pairs[j].vstart:arr.pairs[j].vend])
-		if len(v1) >= len(k1)+1 {
-			v1 = bytes.TrimSpace(v1[len(k1)+1:])
-		}
-		if len(v2) >= len(k2)+1 {
-			v2 = bytes.TrimSpace(v2[len(k2)+1:])
-		}
+		v1 = trimValue(arr.json[arr.pairs[i].kend:arr.pairs[i].jend])
+		v2 = trimValue(arr.json[arr.pairs[j].kend:arr.pairs[j].jend])
 	}
 	t1 := getjtype(v1)
 	t2 := getjtype(v2)
@@ -218,19 +422,57 @@ func (arr *byKeyVal) isLess(i, j int, kind byKind) bool {
 		return false
 	}
 	if t1 == jstring {
-		s1 := parsestr(v1)
-		s2 := parsestr(v2)
-		return string(s1) < string(s2)
+		return lessString(parsestr(v1), parsestr(v2), arr.opts.SortMode)
 	}
 	if t1 == jnumber {
 		n1, _ := strconv.ParseFloat(string(v1), 64)
 		n2, _ := strconv.ParseFloat(string(v2), 64)
 		return n1 < n2
 	}
+	if t1 == jjson {
+		return string(ugly(nil, v1)) < string(ugly(nil, v2))
+	}
 	return string(v1) < string(v2)
 
 }
 
+// keyLess compares two keys using the KeyOrder and KeyLess options.
+func (arr *byKeyVal) keyLess(k1, k2 []byte) bool {
+	if len(arr.opts.KeyOrder) > 0 {
+		r1, r2 := keyRank(arr.opts.KeyOrder, k1), keyRank(arr.opts.KeyOrder, k2)
+		if r1 != r2 {
+			return r1 < r2
+		}
+		if r1 < len(arr.opts.KeyOrder) {
+			return false
+		}
+	}
+	if arr.opts.KeyLess != nil {
+		return arr.opts.KeyLess(k1, k2)
+	}
+	return lessString(k1, k2, arr.opts.SortMode)
+}
+
+// keyRank returns the position of the key in the order, or the length of the
+// order if it's not there.
+func keyRank(order []string, key []byte) int {
+	for i := range order {
+		if order[i] == string(key) {
+			return i
+		}
+	}
+	return len(order)
+}
+
+// trimValue removes the space and colon that comes before an object value.
+func trimValue(v []byte) []byte {
+	v = bytes.TrimSpace(v)
+	if len(v) > 0 && v[0] == ':' {
+		v = bytes.TrimSpace(v[1:])
+	}
+	return v
+}
+
 func parsestr(s []byte) []byte {
 	for i := 1; i < len(s); i++ {
 		if s[i] == '\\' {
@@ -245,63 +487,111 @@ func parsestr(s []byte) []byte {
 	return nil
 }
 
-func appendPrettyObject(buf, json []byte, i int, open, close byte, pretty bool, width int, prefix, indent string, sortkeys bool, tabs, nl, max int) ([]byte, int, int, bool) {
+func appendPrettyObject(buf, json []byte, i int, open, close byte, pretty bool, opts *Options, st *state, tabs, nl, max int) ([]byte, int, int, bool) {
 	var ok bool
+	if open == '[' && st.sorter != nil && !st.sorted && st.sorter.match() {
+		// format a compacted copy of the array with the elements sorted
+		var sorted []byte
+		sorted, i = st.sorter.appendArray(nil, json, i)
+		st.sorted = true
+		buf, _, nl, ok = appendPrettyObject(buf, sorted, 0, open, close,
+			pretty, opts, st, tabs, nl, max)
+		st.sorted = false
+		return buf, i, nl, ok
+	}
+	width := opts.Width
 	if width > 0 {
-		if pretty && open == '[' && max == -1 {
-			// here we try to create a single line array
+		if pretty && (open == '[' || opts.CompactObjects) && max == -1 {
+			// here we try to create a single line array or object
 			max := width - (len(buf) - nl)
 			if max > 3 {
 				s1, s2 := len(buf), i
-				buf, i, _, ok = appendPrettyObject(buf, json, i, '[', ']', false, width, prefix, "", sortkeys, 0, 0, max)
-				if ok && len(buf)-s1 <= max {
-					return buf, i, nl, true
+				var hidden int
+				buf, i, hidden, ok = appendPrettyObject(buf, json, i, open, close, false, opts, st, tabs, 0, max)
+				if ok && len(buf)-s1-hidden <= max {
+					return buf, i, nl + hidden, true
 				}
 				buf = buf[:s1]
 				i = s2
 			}
-		} else if max != -1 && open == '{' {
+		} else if max != -1 && open == '{' && !opts.CompactObjects {
 			return buf, i, nl, false
 		}
 	}
-	buf = append(buf, open)
+	style := opts.Style
+	start, snl := len(buf), nl
+	buf, nl = appendPunct(buf, open, true, style, tabs, nl)
 	i++
+	// wrap is for packing as many scalar elements on each line as will fit
+	wrap := pretty && open == '[' && opts.ArrayWrap && width > 0
+	var scalar bool
 	var pairs []pair
-	if open == '{' && sortkeys {
+	if open == '{' && (opts.SortKeys || opts.DuplicateKeys == DuplicateKeepFirst ||
+		opts.DuplicateKeys == DuplicateKeepLast) {
 		pairs = make([]pair, 0, 8)
 	}
+	// track is for keeping the path of the values for SortArrayPaths
+	track := st.sorter != nil && st.sorter.paths != nil && !st.sorted
 	var n int
 	for ; i < len(json); i++ {
 		if json[i] <= ' ' {
 			continue
 		}
 		if json[i] == close {
-			if pretty {
-				if open == '{' && sortkeys {
-					buf = sortPairs(json, buf, pairs)
+			if pairs != nil {
+				var space byte
+				if pretty {
+					space = '\n'
+				} else if width != -1 {
+					space = ' '
 				}
+				buf = sortPairs(json, buf, pairs, space, opts, tabs)
+			}
+			if pretty {
 				if n > 0 {
+					if opts.TrailingCommas && buf[len(buf)-1] != ' ' {
+						buf, nl = appendPunct(buf, ',', open == '{', style, tabs, nl)
+					}
 					nl = len(buf)
 					if buf[nl-1] == ' ' {
 						buf[nl-1] = '\n'
 					} else {
 						buf = append(buf, '\n')
 					}
-				}
-				if buf[len(buf)-1] != open {
-					buf = appendTabs(buf, prefix, indent, tabs)
+					buf = appendTabs(buf, opts.Prefix, opts.Indent, tabs)
 				}
 			}
-			buf = append(buf, close)
-			return buf, i + 1, nl, open != '{'
+			buf, nl = appendPunct(buf, close, true, style, tabs, nl)
+			return buf, i + 1, nl, open != '{' || opts.CompactObjects
 		}
 		if open == '[' || json[i] == '"' {
+			if open == '{' && opts.OmitNulls {
+				if j := nullMember(json, i); j != -1 {
+					i = j - 1
+					continue
+				}
+			}
 			if n > 0 {
-				buf = append(buf, ',')
-				if width != -1 && open == '[' {
+				buf, nl = appendPunct(buf, ',', open == '{', style, tabs, nl)
+				if width != -1 && (open == '[' || !pretty) {
 					buf = append(buf, ' ')
 				}
 			}
+			if wrap {
+				next := isScalar(json, i)
+				if n > 0 && scalar && next {
+					// try to fit the element at the end of the current line
+					s1, s2, s3 := len(buf), i, nl
+					buf, i, nl, _ = appendPrettyAny(buf, json, i, pretty, opts, st, tabs+1, nl, max)
+					if len(buf)-nl <= width {
+						i--
+						n++
+						continue
+					}
+					buf, i, nl = buf[:s1], s2, s3
+				}
+				scalar = next
+			}
 			var p pair
 			if pretty {
 				nl = len(buf)
@@ -310,31 +600,45 @@ func appendPrettyObject(buf, json []byte, i int, open, close byte, pretty bool,
 				} else {
 					buf = append(buf, '\n')
 				}
-				if open == '{' && sortkeys {
+				if pairs != nil {
 					p.kstart = i
 					p.vstart = len(buf)
 				}
-				buf = appendTabs(buf, prefix, indent, tabs+1)
+				buf = appendTabs(buf, opts.Prefix, opts.Indent, tabs+1)
+			} else if pairs != nil {
+				p.kstart = i
+				p.vstart = len(buf)
 			}
+			var key []byte
 			if open == '{' {
-				buf, i, nl, _ = appendPrettyString(buf, json, i, nl)
-				if sortkeys {
+				k := i
+				buf, i, nl, _ = appendPrettyString(buf, json, i, true, opts, nl)
+				key = json[k:i]
+				if pairs != nil {
 					p.kend = i
 				}
-				buf = append(buf, ':')
-				if pretty {
+				buf, nl = appendPunct(buf, ':', true, style, tabs, nl)
+				if pretty || width != -1 {
 					buf = append(buf, ' ')
 				}
 			}
-			buf, i, nl, ok = appendPrettyAny(buf, json, i, pretty, width, prefix, indent, sortkeys, tabs+1, nl, max)
-			if max != -1 && !ok {
+			if track {
+				st.sorter.push(key)
+			}
+			buf, i, nl, ok = appendPrettyAny(buf, json, i, pretty, opts, st, tabs+1, nl, max)
+			if track {
+				st.sorter.pop()
+			}
+			if max != -1 && (!ok || (open == '[' && len(buf)-start-(nl-snl) > max)) {
+				// the single line does not fit
 				return buf, i, nl, false
 			}
-			if pretty && open == '{' && sortkeys {
+			if pairs != nil {
 				p.vend = len(buf)
+				p.jend = i
 				if p.kstart > p.kend || p.vstart > p.vend {
 					// bad data. disable sorting
-					sortkeys = false
+					pairs = nil
 				} else {
 					pairs = append(pairs, p)
 				}
@@ -343,31 +647,95 @@ func appendPrettyObject(buf, json []byte, i int, open, close byte, pretty bool,
 			n++
 		}
 	}
-	return buf, i, nl, open != '{'
+	return buf, i, nl, open != '{' || opts.CompactObjects
+}
+
+// nullMember returns the index following the object member at i when its
+// value is null, or -1 when it's not.
+func nullMember(json []byte, i int) int {
+	for i = stringEnd(json, i); i < len(json); i++ {
+		if json[i] > ' ' && json[i] != ':' {
+			break
+		}
+	}
+	if len(json)-i >= 4 && string(json[i:i+4]) == "null" {
+		return i + 4
+	}
+	return -1
 }
-func sortPairs(json, buf []byte, pairs []pair) []byte {
+
+// isScalar returns true if the next element in the array at i is not an
+// array or object.
+func isScalar(json []byte, i int) bool {
+	for ; i < len(json); i++ {
+		if json[i] > ' ' && json[i] != ',' {
+			return json[i] != '{' && json[i] != '['
+		}
+	}
+	return true
+}
+
+// sortPairs sorts and removes duplicate members of an object, as requested
+// by the options. The members are separated by a comma that's followed by
+// the space byte, unless it's zero. The depth is of the object.
+func sortPairs(json, buf []byte, pairs []pair, space byte, opts *Options, depth int) []byte {
 	if len(pairs) == 0 {
 		return buf
 	}
 	vstart := pairs[0].vstart
 	vend := pairs[len(pairs)-1].vend
-	arr := byKeyVal{false, json, buf, pairs}
-	sort.Stable(&arr)
-	if !arr.sorted {
+	var changed bool
+	if opts.DuplicateKeys == DuplicateKeepFirst ||
+		opts.DuplicateKeys == DuplicateKeepLast {
+		n := len(pairs)
+		pairs = uniquePairs(json, pairs, opts.DuplicateKeys == DuplicateKeepLast)
+		changed = len(pairs) != n
+	}
+	if opts.SortKeys {
+		arr := byKeyVal{false, json, pairs, opts}
+		sort.Stable(&arr)
+		changed = changed || arr.sorted
+	}
+	if !changed {
 		return buf
 	}
 	nbuf := make([]byte, 0, vend-vstart)
 	for i, p := range pairs {
 		nbuf = append(nbuf, buf[p.vstart:p.vend]...)
 		if i < len(pairs)-1 {
-			nbuf = append(nbuf, ',')
-			nbuf = append(nbuf, '\n')
+			nbuf, _ = appendPunct(nbuf, ',', true, opts.Style, depth, 0)
+			if space != 0 {
+				nbuf = append(nbuf, space)
+			}
 		}
 	}
 	return append(buf[:vstart], nbuf...)
 }
 
-func appendPrettyString(buf, json []byte, i, nl int) ([]byte, int, int, bool) {
+// uniquePairs removes the pairs that have the same key as another pair,
+// keeping either the first or the last one.
+func uniquePairs(json []byte, pairs []pair, last bool) []pair {
+	seen := make(map[string]bool, len(pairs))
+	uniq := make([]pair, 0, len(pairs))
+	for i := range pairs {
+		if last {
+			i = len(pairs) - 1 - i
+		}
+		key := string(parsestr(json[pairs[i].kstart:pairs[i].kend]))
+		if !seen[key] {
+			seen[key] = true
+			uniq = append(uniq, pairs[i])
+		}
+	}
+	if last {
+		for i, j := 0, len(uniq)-1; i < j; i, j = i+1, j-1 {
+			uniq[i], uniq[j] = uniq[j], uniq[i]
+		}
+	}
+	return uniq
+}
+
+func appendPrettyString(buf, json []byte, i int, key bool, opts *Options, nl int) ([]byte, int, int, bool) {
 	s := i
 	i++
 	for ; i < len(json); i++ {
@@ -387,10 +755,41 @@ func appendPrettyString(buf, json []byte, i, nl int) ([]byte, int, int, bool) {
 			break
 		}
 	}
-	return append(buf, json[s:i]...), i, nl, true
+	str := json[s:i]
+	if opts.InvalidUTF8 == UTF8Replace || opts.InvalidUTF8 == UTF8Escape {
+		if j, _ := nextInvalid(str, 0); j != -1 {
+			str = appendFixedString(nil, str, opts.InvalidUTF8)
+		}
+	}
+	if opts.Escapes != EscapeVerbatim {
+		if opts.Style == nil && !opts.JSON5 {
+			return appendReescaped(buf, str, opts.Escapes), i, nl, true
+		}
+		str = appendReescaped(nil, str, opts.Escapes)
+	}
+	if opts.JSON5 {
+		if key {
+			if name := parsestr(str); isIdentifier(name, opts.Escapes) {
+				if opts.Style != nil {
+					buf, nl = appendStyle(buf, name, opts.Style.Key, opts.Style, nl)
+					return buf, i, nl, true
+				}
+				return append(buf, name...), i, nl, true
+			}
+		}
+		if singleQuotes(str) {
+			str = appendSingleQuoted(nil, str)
+		}
+	}
+	if opts.Style != nil {
+		mark := len(buf)
+		buf, _ = appendColorString(buf, str, 0, key, opts.Style)
+		return buf, i, nl + (len(buf) - mark - len(str)), true
+	}
+	return append(buf, str...), i, nl, true
 }
 
-func appendPrettyNumber(buf, json []byte, i, nl int) ([]byte, int, int, bool) {
+func appendPrettyNumber(buf, json []byte, i int, opts *Options, nl int) ([]byte, int, int, bool) {
 	s := i
 	i++
 	for ; i < len(json); i++ {
@@ -398,7 +797,18 @@ func appendPrettyNumber(buf, json []byte, i, nl int) ([]byte, int, int, bool) {
 			break
 		}
 	}
-	return append(buf, json[s:i]...), i, nl, true
+	num := json[s:i]
+	if opts.Numbers != NumbersVerbatim {
+		if opts.Style == nil {
+			return appendNumber(buf, num, opts), i, nl, true
+		}
+		num = appendNumber(nil, num, opts)
+	}
+	if opts.Style != nil {
+		buf, nl = appendStyle(buf, num, opts.Style.Number, opts.Style, nl)
+		return buf, i, nl, true
+	}
+	return append(buf, num...), i, nl, true
 }
 
 func appendTabs(buf []byte, prefix, indent string, tabs int) []byte {
@@ -423,7 +833,13 @@ type Style struct {
 	True, False, Null   [2]string
 	Escape              [2]string
 	Brackets            [2]string
-	Append              func(dst []byte, c byte) []byte
+	// DepthBrackets are the colors of the brackets for each depth, which
+	// Color cycles through, so that the brackets at the same depth have the
+	// same color. When it's empty all of the brackets are Brackets.
+	DepthBrackets [][2]string
+	// Match is for the highlights of ColorWithOptions
+	Match  [2]string
+	Append func(dst []byte, c byte) []byte
 }
 
 func hexp(p byte) byte {
@@ -438,6 +854,10 @@ func hexp(p byte) byte {
 // TerminalStyle is for terminals
 var TerminalStyle *Style
 
+// TerminalRainbowStyle is like TerminalStyle, but the brackets are colored
+// by their depth.
+var TerminalRainbowStyle *Style
+
 func init() {
 	TerminalStyle = &Style{
 		Key:      [2]string{"\x1B[1m\x1B[94m", "\x1B[0m"},
@@ -448,150 +868,121 @@ func init() {
 		Null:     [2]string{"\x1B[2m", "\x1B[0m"},
 		Escape:   [2]string{"\x1B[35m", "\x1B[0m"},
 		Brackets: [2]string{"\x1B[1m", "\x1B[0m"},
-		Append: func(dst []byte, c byte) []byte {
-			if c < ' ' && (c != '\r' && c != '\n' && c != '\t' && c != '\v') {
-				dst = append(dst, "\\u00"...)
-				dst = append(dst, hexp((c>>4)&0xF))
-				return append(dst, hexp((c)&0xF))
-			}
-			return append(dst, c)
-		},
+		Match:    [2]string{"\x1B[7m", "\x1B[0m"},
+		Append:   appendTerminalByte,
+	}
+	rainbow := *TerminalStyle
+	rainbow.DepthBrackets = [][2]string{
+		{"\x1B[1m\x1B[93m", "\x1B[0m"},
+		{"\x1B[1m\x1B[95m", "\x1B[0m"},
+		{"\x1B[1m\x1B[96m", "\x1B[0m"},
 	}
+	TerminalRainbowStyle = &rainbow
 }
 
-// Color will colorize the json. The style parma is used for customizing
-// the colors. Passing nil to the style param will use the default
-// TerminalStyle.
-func Color(src []byte, style *Style) []byte {
-	if style == nil {
-		style = TerminalStyle
+// brackets returns the color of the brackets at the depth, where the root
+// is zero.
+func (s *Style) brackets(depth int) [2]string {
+	if len(s.DepthBrackets) == 0 {
+		return s.Brackets
+	}
+	return s.DepthBrackets[depth%len(s.DepthBrackets)]
+}
+
+// appendTerminalByte appends the byte, with the control characters that
+// would mess up a terminal escaped.
+func appendTerminalByte(dst []byte, c byte) []byte {
+	if c < ' ' && (c != '\r' && c != '\n' && c != '\t' && c != '\v') {
+		dst = append(dst, "\\u00"...)
+		dst = append(dst, hexp((c>>4)&0xF))
+		return append(dst, hexp((c)&0xF))
 	}
+	return append(dst, c)
+}
+
+func appendByte(dst []byte, c byte) []byte {
+	return append(dst, c)
+}
+
+// appendColorString appends the string that starts at src[i] using the key
+// or string color, with escape sequences in the escape color. It returns the
+// index of the closing quote.
+func appendColorString(dst, src []byte, i int, key bool, style *Style) ([]byte, int) {
 	apnd := style.Append
 	if apnd == nil {
-		apnd = func(dst []byte, c byte) []byte {
-			return append(dst, c)
-		}
+		apnd = appendByte
 	}
-	type stackt struct {
-		kind byte
-		key  bool
+	color := style.String
+	if key {
+		color = style.Key
 	}
-	var dst []byte
-	var stack []stackt
-	for i := 0; i < len(src); i++ {
-		if src[i] == '"' {
-			key := len(stack) > 0 && stack[len(stack)-1].key
-			if key {
-				dst = append(dst, style.Key[0]...)
+	quote := src[i]
+	dst = append(dst, color[0]...)
+	dst = apnd(dst, quote)
+	esc := false
+	uesc := 0
+	for i = i + 1; i < len(src); i++ {
+		if src[i] == '\\' {
+			dst = append(dst, color[1]...)
+			dst = append(dst, style.Escape[0]...)
+			dst = apnd(dst, src[i])
+			esc = true
+			if i+1 < len(src) && src[i+1] == 'u' {
+				uesc = 5
 			} else {
-				dst = append(dst, style.String[0]...)
+				uesc = 1
 			}
-			dst = apnd(dst, '"')
-			esc := false
-			uesc := 0
-			for i = i + 1; i < len(src); i++ {
-				if src[i] == '\\' {
-					if key {
-						dst = append(dst, style.Key[1]...)
-					} else {
-						dst = append(dst, style.String[1]...)
-					}
-					dst = append(dst, style.Escape[0]...)
-					dst = apnd(dst, src[i])
-					esc = true
-					if i+1 < len(src) && src[i+1] == 'u' {
-						uesc = 5
-					} else {
-						uesc = 1
-					}
-				} else if esc {
-					dst = apnd(dst, src[i])
-					if uesc == 1 {
-						esc = false
-						dst = append(dst, style.Escape[1]...)
-						if key {
-							dst = append(dst, style.Key[0]...)
-						} else {
-							dst = append(dst, style.String[0]...)
-						}
-					} else {
-						uesc--
-					}
-				} else {
-					dst = apnd(dst, src[i])
-				}
-				if src[i] == '"' {
-					j := i - 1
-					for ; ; j-- {
-						if src[j] != '\\' {
-							break
-						}
-					}
-					if (j-i)%2 != 0 {
-						break
-					}
-				}
-			}
-			if esc {
+		} else if esc {
+			dst = apnd(dst, src[i])
+			if uesc == 1 {
+				esc = false
 				dst = append(dst, style.Escape[1]...)
-			} else if key {
-				dst = append(dst, style.Key[1]...)
+				dst = append(dst, color[0]...)
 			} else {
-				dst = append(dst, style.String[1]...)
+				uesc--
 			}
-		} else if src[i] == '{' || src[i] == '[' {
-			stack = append(stack, stackt{src[i], src[i] == '{'})
-			dst = append(dst, style.Brackets[0]...)
-			dst = apnd(dst, src[i])
-			dst = append(dst, style.Brackets[1]...)
-		} else if (src[i] == '}' || src[i] == ']') && len(stack) > 0 {
-			stack = stack[:len(stack)-1]
-			dst = append(dst, style.Brackets[0]...)
-			dst = apnd(dst, src[i])
-			dst = append(dst, style.Brackets[1]...)
-		} else if (src[i] == ':' || src[i] == ',') && len(stack) > 0 && stack[len(stack)-1].kind == '{' {
-			stack[len(stack)-1].key = !stack[len(stack)-1].key
-			dst = append(dst, style.Brackets[0]...)
-			dst = apnd(dst, src[i])
-			dst = append(dst, style.Brackets[1]...)
 		} else {
-			var kind byte
-			if (src[i] >= '0' && src[i] <= '9') || src[i] == '-' || isNaNOrInf(src[i:]) {
-				kind = '0'
-				dst = append(dst, style.Number[0]...)
-			} else if src[i] == 't' {
-				kind = 't'
-				dst = append(dst, style.True[0]...)
-			} else if src[i] == 'f' {
-				kind = 'f'
-				dst = append(dst, style.False[0]...)
-			} else if src[i] == 'n' {
-				kind = 'n'
-				dst = append(dst, style.Null[0]...)
-			} else {
-				dst = apnd(dst, src[i])
-			}
-			if kind != 0 {
-				for ; i < len(src); i++ {
-					if src[i] <= ' ' || src[i] == ',' || src[i] == ':' || src[i] == ']' || src[i] == '}' {
-						i--
-						break
-					}
-					dst = apnd(dst, src[i])
-				}
-				if kind == '0' {
-					dst = append(dst, style.Number[1]...)
-				} else if kind == 't' {
-					dst = append(dst, style.True[1]...)
-				} else if kind == 'f' {
-					dst = append(dst, style.False[1]...)
-				} else if kind == 'n' {
-					dst = append(dst, style.Null[1]...)
+			dst = apnd(dst, src[i])
+		}
+		if src[i] == quote {
+			j := i - 1
+			for ; ; j-- {
+				if src[j] != '\\' {
+					break
 				}
 			}
+			if (j-i)%2 != 0 {
+				break
+			}
 		}
 	}
-	return dst
+	if esc {
+		dst = append(dst, style.Escape[1]...)
+	} else {
+		dst = append(dst, color[1]...)
+	}
+	return dst, i
+}
+
+// Color will colorize the json. The style parma is used for customizing
+// the colors. Passing nil to the style param will use the default
+// TerminalStyle.
+func Color(src []byte, style *Style) []byte {
+	return ColorWithOptions(src, &ColorOptions{Style: style})
+}
+
+// ColorE is like ColorWithOptions but it returns a *SyntaxError, and no
+// output, when the input is not valid json. Like PrettyE the input is
+// checked in its own pass first.
+func ColorE(src []byte, opts *ColorOptions) ([]byte, error) {
+	v := &validator{}
+	if opts != nil {
+		v = newValidator(opts.DuplicateKeys, opts.InvalidUTF8)
+	}
+	if err := v.validate(src); err != nil {
+		return nil, err
+	}
+	return ColorWithOptions(src, opts), nil
 }
 
 // Spec strips out comments and trailing commas and convert the input to a
@@ -569,3 +569,254 @@ func TestNaN(t *testing.T) {
 		}
 	}
 }
+
+func TestDuplicateKeys(t *testing.T) {
+	json := `{"a":1,"b":{"x":1,"x":2},"a":2,"a":3,"c":[{"d":1,"d":2}]}`
+	tests := []struct {
+		policy DuplicatePolicy
+		sort   bool
+		expect string
+	}{
+		{DuplicateKeepAll, false, json},
+		{DuplicateKeepFirst, false, `{"a":1,"b":{"x":1},"c":[{"d":1}]}`},
+		{DuplicateKeepLast, false, `{"b":{"x":2},"a":3,"c":[{"d":2}]}`},
+		{DuplicateKeepFirst, true, `{"a":1,"b":{"x":1},"c":[{"d":1}]}`},
+		{DuplicateKeepLast, true, `{"a":3,"b":{"x":2},"c":[{"d":2}]}`},
+		{DuplicateError, false, json},
+	}
+	for _, tt := range tests {
+		opts := *DefaultOptions
+		opts.DuplicateKeys = tt.policy
+		opts.SortKeys = tt.sort
+		res := string(UglyOptions([]byte(json), &opts))
+		if res != tt.expect {
+			t.Fatalf("expected '%s', got '%s'", tt.expect, res)
+		}
+		res = string(Ugly(PrettyOptions([]byte(json), &opts)))
+		if res != tt.expect {
+			t.Fatalf("expected '%s', got '%s'", tt.expect, res)
+		}
+	}
+	opts := *DefaultOptions
+	opts.DuplicateKeys = DuplicateError
+	_, err := PrettyE([]byte(json), &opts)
+	serr, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("expected *SyntaxError, got %v", err)
+	}
+	assertEqual(t, 18, serr.Offset)
+	assertEqual(t, `pretty: duplicate key "x" at line 1, column 19, `+
+		`expected unique key`, serr.Error())
+	_, err = PrettyE([]byte(`{"a":{"a":1},"b":{"a":2,"b":3}}`), &opts)
+	assertEqual(t, nil, err)
+
+	// the compact path has the same policy
+	_, err = UglyE([]byte(json), &opts)
+	assertEqual(t, serr.Error(), err.Error())
+	res, err := UglyE([]byte(`{"b":{"a":2, "b":3},"a":{"a":1}}`), &opts)
+	assertEqual(t, nil, err)
+	assertEqual(t, `{"b":{"a":2,"b":3},"a":{"a":1}}`, string(res))
+}
+
+func TestUglyOptions(t *testing.T) {
+	assertEqual(t, string(Ugly(example1)), string(UglyOptions(example1, nil)))
+	assertEqual(t, string(Ugly(example1)),
+		string(UglyOptions(example1, DefaultOptions)))
+	opts := *DefaultOptions
+	opts.SortKeys = true
+	assertEqual(t, string(Ugly(PrettyOptions(example1, &opts))),
+		string(UglyOptions(example1, &opts)))
+}
+
+func TestCompactObjects(t *testing.T) {
+	json := `{"points":[{"x":1,"y":2},{"x":3,"y":4}],"name":{"first":"Janet",` +
+		`"last":"Sanders","address":{"street":"123 Main Street","city":"Tempe"}},` +
+		`"empty":{}}`
+	opts := *DefaultOptions
+	opts.CompactObjects = true
+	expect := `{
+  "points": [{"x": 1, "y": 2}, {"x": 3, "y": 4}],
+  "name": {
+    "first": "Janet",
+    "last": "Sanders",
+    "address": {"street": "123 Main Street", "city": "Tempe"}
+  },
+  "empty": {}
+}
+`
+	res := string(PrettyOptions([]byte(json), &opts))
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	opts.SortKeys = true
+	expect = `{
+  "empty": {},
+  "name": {
+    "address": {"city": "Tempe", "street": "123 Main Street"},
+    "first": "Janet",
+    "last": "Sanders"
+  },
+  "points": [{"x": 1, "y": 2}, {"x": 3, "y": 4}]
+}
+`
+	res = string(PrettyOptions([]byte(json), &opts))
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	res = string(PrettyOptions([]byte(`{"b":2,"a":1}`), &opts))
+	assertEqual(t, "{\"a\": 1, \"b\": 2}\n", res)
+	assertEqual(t, `{"a":1,"b":2}`, string(UglyOptions([]byte(`{"b":2,"a":1}`), &opts)))
+}
+
+func TestArrayWrap(t *testing.T) {
+	json := `{"nums":[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,` +
+		`23,24,25],"mixed":["alpha","beta",{"a":[1,2]},"gamma","delta",` +
+		`"epsilon","zeta"],"short":[1,2,3]}`
+	opts := Options{Width: 40, Indent: "  ", ArrayWrap: true}
+	expect := `{
+  "nums": [
+    1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
+    12, 13, 14, 15, 16, 17, 18, 19, 20,
+    21, 22, 23, 24, 25
+  ],
+  "mixed": [
+    "alpha", "beta",
+    {
+      "a": [1, 2]
+    },
+    "gamma", "delta", "epsilon", "zeta"
+  ],
+  "short": [1, 2, 3]
+}
+`
+	res := string(PrettyOptions([]byte(json), &opts))
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	assertEqual(t, j(json), j(res))
+}
+
+func TestPrettyStyle(t *testing.T) {
+	jsons := []string{
+		string(example1),
+		example2,
+		`{"a":"b\n\u0001c","d":[1,2,{"e":null,"f":true}],"g":{},"h":[],` +
+			`"key with \"quotes\"":false,"` + "\x01" + `":[-1.5e10,NaN]}`,
+		`[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23,24,25,` +
+			`"alpha","beta",{"x":1,"y":2},{"x":3,"y":4,"z":"` +
+			strings.Repeat("long ", 20) + `"}]`,
+	}
+	for _, json := range jsons {
+		for _, opts := range []Options{
+			*DefaultOptions,
+			{Width: 30, Prefix: "> ", Indent: "\t", SortKeys: true},
+			{Width: 40, Indent: "  ", CompactObjects: true},
+			{Width: 40, Indent: "  ", ArrayWrap: true},
+			{Width: 40, Indent: "  ", ArrayWrap: true, CompactObjects: true,
+				SortKeys: true},
+		} {
+			for _, style := range []*Style{TerminalStyle, TerminalRainbowStyle} {
+				opts.Style = nil
+				expect := string(Color(PrettyOptions([]byte(json), &opts), style))
+				opts.Style = style
+				res := string(PrettyOptions([]byte(json), &opts))
+				if res != expect {
+					t.Fatalf("expected '%s', got '%s'", expect, res)
+				}
+				var out bytes.Buffer
+				e := NewEncoder(&out, &opts)
+				e.bufsize = 0
+				e.ReadFrom(strings.NewReader(json))
+				if out.String() != expect {
+					t.Fatalf("expected '%s', got '%s'", expect, out.String())
+				}
+			}
+		}
+	}
+	for _, style := range []*Style{TerminalStyle, TerminalRainbowStyle} {
+		opts := Options{Style: style}
+		expect := string(Color(Ugly(example1), style))
+		assertEqual(t, expect, string(UglyOptions(example1, &opts)))
+	}
+}
+
+func TestKeyOrder(t *testing.T) {
+	json := `{"b":1,"name":2,"a":3,"id":4,"c":{"type":5,"z":6,"id":7},"type":8}`
+	opts := Options{SortKeys: true, KeyOrder: []string{"id", "type", "name"}}
+	res := string(UglyOptions([]byte(json), &opts))
+	expect := `{"id":4,"type":8,"name":2,"a":3,"b":1,"c":{"id":7,"type":5,"z":6}}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	opts.KeyLess = func(a, b []byte) bool { return string(a) > string(b) }
+	res = string(UglyOptions([]byte(json), &opts))
+	expect = `{"id":4,"type":8,"name":2,"c":{"id":7,"type":5,"z":6},"b":1,"a":3}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	opts.KeyOrder = nil
+	res = string(UglyOptions([]byte(`{"a":1,"c":2,"b":3,"a":0}`), &opts))
+	expect = `{"c":2,"b":3,"a":0,"a":1}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+}
+
+func TestOmitNulls(t *testing.T) {
+	json := `{"a":null,"b":[null,{"c" : null}],"d":{"e":1, "f":null},"g":null}`
+	opts := Options{OmitNulls: true}
+	res := string(UglyOptions([]byte(json), &opts))
+	expect := `{"b":[null,{}],"d":{"e":1}}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	opts = Options{Width: 80, Indent: "  ", OmitNulls: true, SortKeys: true}
+	res = string(PrettyOptions([]byte(`{"b":1,"z":null,"a":"nullable"}`), &opts))
+	expect = "{\n  \"a\": \"nullable\",\n  \"b\": 1\n}\n"
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+}
+
+func TestTrailingCommas(t *testing.T) {
+	json := `{"a":[1,2],"b":[{"c":true},[]],"d":{}}`
+	opts := Options{Width: 16, Indent: "  ", TrailingCommas: true}
+	res := string(PrettyOptions([]byte(json), &opts))
+	expect := "{\n  \"a\": [1, 2],\n  \"b\": [\n    {\n      \"c\": true,\n" +
+		"    },\n    [],\n  ],\n  \"d\": {},\n}\n"
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	res = string(UglyOptions([]byte(json), &opts))
+	expect = `{"a":[1,2],"b":[{"c":true},[]],"d":{}}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+}
+
+func TestJSON5Output(t *testing.T) {
+	json := `{"name":"a \"quoted\" name","it's":"it's","$_1":1,"1a":2,` +
+		`"été":"\"'\""}`
+	opts := Options{JSON5: true}
+	res := string(UglyOptions([]byte(json), &opts))
+	expect := `{name:'a "quoted" name',"it's":"it's",$_1:1,"1a":2,` +
+		`été:'"\'"'}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	opts.Escapes = EscapeASCII
+	res = string(UglyOptions([]byte(json), &opts))
+	expect = `{name:'a "quoted" name',"it's":"it's",$_1:1,"1a":2,` +
+		`"\u00e9t\u00e9":'"\'"'}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+
+	// the output converts back to the same json
+	opts = Options{Width: 80, Indent: "  ", JSON5: true, TrailingCommas: true}
+	res5, err := JSON5ToJSON(PrettyOptions([]byte(json), &opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(Ugly([]byte(json))), string(Ugly(res5)))
+}
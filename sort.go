@@ -0,0 +1,594 @@
+package pretty
+
+import (
+	"sort"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SortMode is how SortKeys compares strings
+type SortMode int
+
+const (
+	// SortBytes compares the bytes of the strings
+	SortBytes SortMode = iota
+	// SortCaseInsensitive compares the strings ignoring case
+	SortCaseInsensitive
+	// SortNatural compares runs of digits by their numeric value, so that
+	// "item2" comes before "item10"
+	SortNatural
+	// SortCollate compares strings by folding the accents of the Latin
+	// letters, which is an approximation of the default order of the Unicode
+	// Collation Algorithm and not the full algorithm. Letters are compared
+	// first by their base letter, then by their accents in the order of the
+	// algorithm, such as "é" before "è", and then by case, with lowercase
+	// first. Letters outside of the Latin-1 Supplement and Latin Extended-A
+	// blocks are ordered by code point.
+	SortCollate
+)
+
+// lessString compares two decoded strings using the sort mode. Strings that
+// are equal for the mode fall back to comparing bytes, which keeps the
+// ordering stable between runs.
+func lessString(a, b []byte, mode SortMode) bool {
+	var c int
+	switch mode {
+	case SortCaseInsensitive:
+		c = compareFold(a, b)
+	case SortNatural:
+		c = compareNatural(a, b)
+	case SortCollate:
+		c = compareCollate(a, b)
+	}
+	if c != 0 {
+		return c < 0
+	}
+	return string(a) < string(b)
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+func compareFold(a, b []byte) int {
+	for len(a) > 0 && len(b) > 0 {
+		r1, n1 := utf8.DecodeRune(a)
+		r2, n2 := utf8.DecodeRune(b)
+		if c := compareInts(int(unicode.ToLower(r1)), int(unicode.ToLower(r2))); c != 0 {
+			return c
+		}
+		a, b = a[n1:], b[n2:]
+	}
+	return compareInts(len(a), len(b))
+}
+
+func compareNatural(a, b []byte) int {
+	for len(a) > 0 && len(b) > 0 {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			var d1, d2 []byte
+			d1, a = digitRun(a)
+			d2, b = digitRun(b)
+			if c := compareInts(len(d1), len(d2)); c != 0 {
+				return c
+			}
+			if c := compareInts(bytesCompare(d1, d2), 0); c != 0 {
+				return c
+			}
+			continue
+		}
+		if c := compareInts(int(a[0]), int(b[0])); c != 0 {
+			return c
+		}
+		a, b = a[1:], b[1:]
+	}
+	return compareInts(len(a), len(b))
+}
+
+// digitRun returns the digits at the start of s, without the leading zeros,
+// and the rest of s.
+func digitRun(s []byte) ([]byte, []byte) {
+	var i int
+	for i < len(s)-1 && s[i] == '0' && isDigit(s[i+1]) {
+		i++
+	}
+	j := i
+	for j < len(s) && isDigit(s[j]) {
+		j++
+	}
+	return s[i:j], s[j:]
+}
+
+func bytesCompare(a, b []byte) int {
+	if string(a) < string(b) {
+		return -1
+	}
+	if string(a) > string(b) {
+		return 1
+	}
+	return 0
+}
+
+// compareCollate compares the strings at three levels: the base letters,
+// then the accents, and then the case. Each level walks the strings without
+// making collation keys, so it does not allocate.
+func compareCollate(a, b []byte) int {
+	for level := 1; level <= 3; level++ {
+		c1 := collator{s: a, level: level}
+		c2 := collator{s: b, level: level}
+		for {
+			w1, ok1 := c1.next()
+			w2, ok2 := c2.next()
+			if !ok1 || !ok2 {
+				// the shorter string is first
+				if ok1 {
+					return 1
+				} else if ok2 {
+					return -1
+				}
+				break
+			}
+			if c := compareInts(int(w1), int(w2)); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// The primary collation weights put spaces and punctuation first, followed
+// by digits, letters, and then everything else.
+const (
+	collatePunct  = 0 << 21
+	collateDigit  = 1 << 21
+	collateLetter = 2 << 21
+	collateOther  = 3 << 21
+)
+
+// collator returns the weights of a string for one level of compareCollate.
+// Each base letter has a weight at every level, so a letter that expands to
+// two base letters, such as "ß", has two weights.
+type collator struct {
+	s     []byte
+	level int
+	// rest is the rest of the base letters of an expansion
+	rest string
+	// case3 is the weight at the third level for the rest
+	case3 rune
+}
+
+func (c *collator) next() (rune, bool) {
+	var r, accent rune
+	if c.rest != "" {
+		r = rune(c.rest[0])
+		c.rest = c.rest[1:]
+	} else {
+		if len(c.s) == 0 {
+			return 0, false
+		}
+		orig, n := utf8.DecodeRune(c.s)
+		c.s = c.s[n:]
+		r = unicode.ToLower(orig)
+		c.case3 = 0
+		if unicode.IsUpper(orig) {
+			c.case3 = 1
+		}
+		if base := baseLetters(r); base != "" {
+			if len(base) > 1 {
+				// after the letters that it expands to
+				c.case3 += 2
+			} else {
+				accent = rune(latinAccent[r-0xDF])
+				if accent >= 'a' {
+					accent -= 'a' - 10
+				} else {
+					accent -= '0'
+				}
+			}
+			r, c.rest = rune(base[0]), base[1:]
+		}
+	}
+	switch c.level {
+	case 1:
+		switch {
+		case r >= '0' && r <= '9':
+			return collateDigit | r, true
+		case r >= 'a' && r <= 'z':
+			return collateLetter | r, true
+		case r < utf8.RuneSelf || unicode.IsSpace(r) ||
+			unicode.IsPunct(r) || unicode.IsSymbol(r):
+			return collatePunct | r, true
+		}
+		return collateOther | r, true
+	case 2:
+		return accent, true
+	}
+	return c.case3, true
+}
+
+// latinBase are the base letters of the lowercase Latin-1 Supplement and
+// Latin Extended-A letters, starting at U+00DF. Letters that expand to more
+// than one base letter are looked up in latinExpand.
+const latinBase = "" +
+	"\x00aaaaaa\x00ceeeeiiiidnooooo\x00ouuuuy\x00y" + // U+00DF-U+00FF
+	"aaaaaaccccccccddddeeeeeeeeeegggggggghhhhiiiiiiiiii\x00\x00jjkkk" +
+	"llllllllllnnnnnnnnnoooooo\x00\x00rrrrrrsssssssstttttt" +
+	"uuuuuuuuuuuuwwyyyzzzzzzs" // U+0100-U+017F
+
+var latinExpand = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'þ': "th",
+	'ĳ': "ij", 'Ĳ': "ij", 'œ': "oe", 'Œ': "oe",
+}
+
+// latinAccent are the accents of the letters in latinBase, in the order of
+// their secondary weights in the Unicode Collation Algorithm: none, acute,
+// grave, breve, circumflex, caron, ring, diaeresis, double acute, tilde, dot
+// above, the stroked and other letters that don't decompose, cedilla,
+// ogonek, and macron, as 0 to 9 and then a to e.
+const latinAccent = "" +
+	"02149760c21472147b9214970b2147107" + // U+00DF-U+00FF
+	"ee33dd1144aa5555bbee33aadd554433aacc44bb99ee33ddab0044ccb" +
+	"11cc55bbbb11cc55bbbee33880011cc551144cc55cc55bb" +
+	"99ee336688dd4444711aa55b" // U+0100-U+017F
+
+// baseLetters returns the base letters of a lowercase Latin letter with
+// diacritics, or an empty string if it's not one.
+func baseLetters(r rune) string {
+	if s, ok := latinExpand[r]; ok {
+		return s
+	}
+	if r >= 0xDF && r < 0xDF+rune(len(latinBase)) && latinBase[r-0xDF] != 0 {
+		return latinBase[r-0xDF : r-0xDF+1]
+	}
+	return ""
+}
+
+// arraySorter returns the sorter for the arrays that are selected by
+// SortArrayPaths, or nil when SortArrays is false.
+func (opts *Options) arraySorter() *arraySorter {
+	if !opts.SortArrays {
+		return nil
+	}
+	s := &arraySorter{opts: *opts}
+	s.opts.Style = nil
+	for _, path := range opts.SortArrayPaths {
+		s.paths = append(s.paths, splitPath(path))
+	}
+	return s
+}
+
+type arraySorter struct {
+	opts  Options
+	paths [][]string
+	path  []pathElem
+}
+
+// pathElem is an object key, or an array element when index is true.
+type pathElem struct {
+	key   string
+	index bool
+}
+
+// splitPath splits the path on the dots that are not escaped with a
+// backslash.
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	var parts []string
+	var part []byte
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path):
+			i++
+			part = append(part, path[i])
+		case path[i] == '.':
+			parts = append(parts, string(part))
+			part = part[:0]
+		default:
+			part = append(part, path[i])
+		}
+	}
+	return append(parts, string(part))
+}
+
+// push adds the member with the key to the path, or an array element when
+// the key is nil.
+func (s *arraySorter) push(key []byte) {
+	if key == nil {
+		s.path = append(s.path, pathElem{index: true})
+	} else {
+		s.path = append(s.path, pathElem{key: string(parsestr(key))})
+	}
+}
+
+func (s *arraySorter) pop() {
+	s.path = s.path[:len(s.path)-1]
+}
+
+// match returns true when the array at the current path should be sorted.
+func (s *arraySorter) match() bool {
+	return s.paths == nil || matchPaths(s.paths, s.path)
+}
+
+// matchPaths returns true when the path matches one of the split paths,
+// where a "*" matches any key or element, and a "#" matches any element.
+func matchPaths(paths [][]string, path []pathElem) bool {
+	for _, parts := range paths {
+		if len(parts) != len(path) {
+			continue
+		}
+		ok := true
+		for i, part := range parts {
+			elem := path[i]
+			if part != "*" && (elem.index && part != "#" ||
+				!elem.index && part != elem.key) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *arraySorter) appendValue(buf, json []byte, i int) ([]byte, int) {
+	for ; i < len(json); i++ {
+		if json[i] > ' ' && json[i] != ':' {
+			break
+		}
+	}
+	if i == len(json) {
+		return buf, i
+	}
+	switch json[i] {
+	case '{':
+		return s.appendObject(buf, json, i)
+	case '[':
+		return s.appendArray(buf, json, i)
+	case '"':
+		j := stringEnd(json, i)
+		return append(buf, json[i:j]...), j
+	}
+	j := i
+	for ; j < len(json); j++ {
+		if c := json[j]; c <= ' ' || c == ',' || c == ':' || c == ']' ||
+			c == '}' || c == '[' || c == '{' || c == '"' {
+			break
+		}
+	}
+	return append(buf, json[i:j]...), j
+}
+
+func (s *arraySorter) appendObject(buf, json []byte, i int) ([]byte, int) {
+	buf = append(buf, '{')
+	var pairs []pair
+	sortKeys := s.opts.SortKeys || s.opts.DuplicateKeys == DuplicateKeepFirst ||
+		s.opts.DuplicateKeys == DuplicateKeepLast
+	for i++; i < len(json); i++ {
+		if json[i] == '}' {
+			if sortKeys {
+				buf = sortPairs(json, buf, pairs, 0, &s.opts, 0)
+			}
+			return append(buf, '}'), i + 1
+		}
+		if json[i] != '"' {
+			continue
+		}
+		if len(pairs) > 0 {
+			buf = append(buf, ',')
+		}
+		var p pair
+		p.kstart, p.vstart = i, len(buf)
+		p.kend = stringEnd(json, i)
+		buf = append(buf, json[p.kstart:p.kend]...)
+		buf = append(buf, ':')
+		if s.paths != nil {
+			s.push(json[p.kstart:p.kend])
+		}
+		buf, i = s.appendValue(buf, json, p.kend)
+		if s.paths != nil {
+			s.pop()
+		}
+		p.vend, p.jend = len(buf), i
+		pairs = append(pairs, p)
+		i--
+	}
+	return buf, i
+}
+
+func (s *arraySorter) appendArray(buf, json []byte, i int) ([]byte, int) {
+	buf = append(buf, '[')
+	var elems []pair
+	for i++; i < len(json); i++ {
+		c := json[i]
+		if c <= ' ' || c == ',' {
+			continue
+		}
+		if c == ']' {
+			break
+		}
+		mark := len(buf)
+		if len(elems) > 0 {
+			buf = append(buf, ',')
+		}
+		start, p := i, pair{vstart: len(buf)}
+		if s.paths != nil {
+			s.push(nil)
+		}
+		buf, i = s.appendValue(buf, json, i)
+		if s.paths != nil {
+			s.pop()
+		}
+		if i == start {
+			// not a value
+			buf = buf[:mark]
+			i++
+		} else {
+			p.vend, p.jend = len(buf), i
+			elems = append(elems, p)
+		}
+		i--
+	}
+	if len(elems) > 1 && s.match() {
+		buf = sortElems(buf, elems, s.opts.SortMode)
+	}
+	if i < len(json) {
+		i++
+	}
+	return append(buf, ']'), i
+}
+
+// sortElems sorts the array elements that are in the buf.
+func sortElems(buf []byte, elems []pair, mode SortMode) []byte {
+	start := elems[0].vstart
+	arr := byValue{false, buf, elems, mode}
+	sort.Stable(&arr)
+	if !arr.sorted {
+		return buf
+	}
+	nbuf := make([]byte, 0, len(buf)-start)
+	for i, p := range elems {
+		if i > 0 {
+			nbuf = append(nbuf, ',')
+		}
+		nbuf = append(nbuf, buf[p.vstart:p.vend]...)
+	}
+	return append(buf[:start], nbuf...)
+}
+
+type byValue struct {
+	sorted bool
+	buf    []byte
+	elems  []pair
+	mode   SortMode
+}
+
+func (arr *byValue) Len() int {
+	return len(arr.elems)
+}
+func (arr *byValue) Less(i, j int) bool {
+	v1 := arr.buf[arr.elems[i].vstart:arr.elems[i].vend]
+	v2 := arr.buf[arr.elems[j].vstart:arr.elems[j].vend]
+	if c := compareJSON(v1, v2, arr.mode); c != 0 {
+		return c < 0
+	}
+	return string(v1) < string(v2)
+}
+func (arr *byValue) Swap(i, j int) {
+	arr.elems[i], arr.elems[j] = arr.elems[j], arr.elems[i]
+	arr.sorted = true
+}
+
+// compareJSON compares two compacted values by their type, and then by
+// their value. Arrays and objects are compared element by element, with
+// the object keys and values being elements.
+func compareJSON(a, b []byte, mode SortMode) int {
+	t1, t2 := getjtype(a), getjtype(b)
+	if t1 != t2 {
+		return compareInts(int(t1), int(t2))
+	}
+	switch t1 {
+	case jstring:
+		s1, s2 := parsestr(a), parsestr(b)
+		if lessString(s1, s2, mode) {
+			return -1
+		}
+		if lessString(s2, s1, mode) {
+			return 1
+		}
+	case jnumber:
+		n1, _ := strconv.ParseFloat(string(a), 64)
+		n2, _ := strconv.ParseFloat(string(b), 64)
+		if n1 < n2 {
+			return -1
+		}
+		if n1 > n2 {
+			return 1
+		}
+	case jjson:
+		if a[0] != b[0] {
+			return compareInts(int(a[0]), int(b[0]))
+		}
+		i, j := 1, 1
+		for {
+			i, j = skipSeps(a, i), skipSeps(b, j)
+			done1 := i == len(a) || a[i] == ']' || a[i] == '}'
+			done2 := j == len(b) || b[j] == ']' || b[j] == '}'
+			if done1 || done2 {
+				if done1 && done2 {
+					return 0
+				}
+				if done1 {
+					return -1
+				}
+				return 1
+			}
+			e1, e2 := valueEnd(a, i), valueEnd(b, j)
+			if c := compareJSON(a[i:e1], b[j:e2], mode); c != 0 {
+				return c
+			}
+			i, j = e1, e2
+		}
+	}
+	return 0
+}
+
+func skipSeps(json []byte, i int) int {
+	for i < len(json) && (json[i] == ',' || json[i] == ':') {
+		i++
+	}
+	return i
+}
+
+// stringEnd returns the index following the string that starts at i.
+func stringEnd(json []byte, i int) int {
+	for i++; i < len(json); i++ {
+		if json[i] == '\\' {
+			i++
+		} else if json[i] == '"' {
+			return i + 1
+		}
+	}
+	return len(json)
+}
+
+// valueEnd returns the index following the compacted value that starts at
+// i, which is always greater than i.
+func valueEnd(json []byte, i int) int {
+	var depth int
+	for j := i; j < len(json); j++ {
+		switch json[j] {
+		case '"':
+			j = stringEnd(json, j) - 1
+		case '[', '{':
+			depth++
+		case ']', '}':
+			if depth == 0 {
+				if j == i {
+					return i + 1
+				}
+				return j
+			}
+			if depth--; depth == 0 {
+				return j + 1
+			}
+		case ',', ':':
+			if depth == 0 {
+				if j == i {
+					return i + 1
+				}
+				return j
+			}
+		}
+	}
+	return len(json)
+}
@@ -0,0 +1,127 @@
+package pretty
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSortMode(t *testing.T) {
+	tests := []struct {
+		mode   SortMode
+		json   string
+		expect string
+	}{
+		{SortBytes,
+			`{"b":1,"B":2,"a10":3,"a2":4,"é":5,"e":6,"f":7}`,
+			`{"B":2,"a10":3,"a2":4,"b":1,"e":6,"f":7,"é":5}`},
+		{SortCaseInsensitive,
+			`{"b":1,"B":2,"a":3,"C":4,"ä":5,"Ä":6}`,
+			`{"a":3,"B":2,"b":1,"C":4,"Ä":6,"ä":5}`},
+		{SortNatural,
+			`{"a10":1,"a2":2,"a02":3,"a1b":4,"a":5,"b1":6,"a10x":7}`,
+			`{"a":5,"a1b":4,"a02":3,"a2":2,"a10":1,"a10x":7,"b1":6}`},
+		{SortCollate,
+			`{"f":1,"É":2,"é":3,"e":4,"E":5,"ß":6,"sr":7,"st":8,"1":9,"_":10}`,
+			`{"_":10,"1":9,"e":4,"E":5,"é":3,"É":2,"f":1,"sr":7,"ß":6,"st":8}`},
+		{SortCollate,
+			`{"Zoë":1,"zoe":2,"Ångström":3,"Anders":4,"Œuvre":5,"Oeuvre":6}`,
+			`{"Anders":4,"Ångström":3,"Oeuvre":6,"Œuvre":5,"zoe":2,"Zoë":1}`},
+		{SortCollate,
+			`{"è":1,"é":2,"ê":3,"ë":4,"e":5,"ex":6,"ē":7}`,
+			`{"e":5,"é":2,"è":1,"ê":3,"ë":4,"ē":7,"ex":6}`},
+	}
+	for _, tt := range tests {
+		opts := Options{SortKeys: true, SortMode: tt.mode}
+		res := string(UglyOptions([]byte(tt.json), &opts))
+		if res != tt.expect {
+			t.Fatalf("mode %d: expected '%s', got '%s'", tt.mode, tt.expect, res)
+		}
+	}
+
+	// string values are compared the same way when the keys are equal
+	opts := Options{SortKeys: true, SortMode: SortNatural}
+	res := string(UglyOptions([]byte(`{"a":"v10","a":"v9","a":"V1"}`), &opts))
+	expect := `{"a":"V1","a":"v9","a":"v10"}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+
+	// the collation does not allocate
+	allocs := testing.AllocsPerRun(100, func() {
+		compareCollate([]byte("Œuvre Ångström"), []byte("oeuvre angstrom"))
+	})
+	assertEqual(t, 0.0, allocs)
+
+	// KeyOrder is still applied first
+	opts = Options{SortKeys: true, SortMode: SortNatural, KeyOrder: []string{"id"}}
+	res = string(UglyOptions([]byte(`{"k10":1,"k9":2,"id":3}`), &opts))
+	expect = `{"id":3,"k9":2,"k10":1}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+}
+
+func TestSortArrays(t *testing.T) {
+	json := `{"tags":["b","a",3,null,true,{"x":1},[2],[1,2],false,1.5],` +
+		`"users":[{"name":"b","roles":["w","r"]},{"roles":["z","a"],"name":"a"}]}`
+	opts := Options{SortArrays: true}
+	res := string(UglyOptions([]byte(json), &opts))
+	// the objects are compared with their keys in the input order
+	expect := `{"tags":[null,false,1.5,3,"a","b",true,[1,2],[2],{"x":1}],` +
+		`"users":[{"name":"b","roles":["r","w"]},{"roles":["a","z"],"name":"a"}]}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	opts.SortKeys = true
+	expect = `{"tags":[null,false,1.5,3,"a","b",true,[1,2],[2],{"x":1}],` +
+		`"users":[{"name":"a","roles":["a","z"]},{"name":"b","roles":["r","w"]}]}`
+	res = string(UglyOptions([]byte(json), &opts))
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+
+	// only the arrays that match a path are sorted
+	opts.SortArrayPaths = []string{"users.#.roles"}
+	res = string(UglyOptions([]byte(json), &opts))
+	expect = `{"tags":["b","a",3,null,true,{"x":1},[2],[1,2],false,1.5],` +
+		`"users":[{"name":"b","roles":["r","w"]},{"name":"a","roles":["a","z"]}]}`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	opts.SortArrayPaths = []string{"*", "", "*.a"}
+	res = string(UglyOptions([]byte(`[[3,1],{"a":[2,1],"b.c":[4,3]},[[2,1]]]`), &opts))
+	expect = `[[1,3],[[2,1]],{"a":[1,2],"b.c":[4,3]}]`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+	opts.SortArrayPaths = []string{`#.b\.c`}
+	res = string(UglyOptions([]byte(`[[3,1],{"a":[2,1],"b.c":[4,3]},[[2,1]]]`), &opts))
+	expect = `[[3,1],{"a":[2,1],"b.c":[3,4]},[[2,1]]]`
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+
+	// the sorted arrays are formatted as usual
+	opts = Options{Width: 80, Indent: "  ", SortArrays: true}
+	res = string(PrettyOptions([]byte(`{"a":[3, 2, 1], "b": [["z", "y"], "x"]}`), &opts))
+	expect = "{\n  \"a\": [1, 2, 3],\n  \"b\": [\"x\", [\"y\", \"z\"]]\n}\n"
+	if res != expect {
+		t.Fatalf("expected '%s', got '%s'", expect, res)
+	}
+}
+
+func TestSortArraysRandom(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	b := make([]byte, 64)
+	opts := Options{SortArrays: true, SortKeys: true}
+	for i := 0; i < 100000; i++ {
+		for j := range b {
+			b[j] = "{}[],:\"\\ 1an"[rand.Intn(12)]
+		}
+		res := UglyOptions(b, &opts)
+		if Valid(b) && !Valid(res) {
+			t.Fatalf("invalid result for %s: %s", b, res)
+		}
+	}
+}
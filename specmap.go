@@ -0,0 +1,77 @@
+package pretty
+
+import "sort"
+
+// SpecMap is like Spec, but rather than keeping the offsets by replacing the
+// comments and trailing commas with spaces, it removes them, and returns an
+// OffsetMap that translates the offsets of the result back to the input. It
+// also converts JSON5, the same as JSON5ToJSON, which changes the lengths of
+// things like unquoted keys and single quoted strings.
+//
+// When the input is not valid JSON5 the result is the same as Spec, where
+// the offsets are unchanged.
+func SpecMap(src []byte) ([]byte, *OffsetMap) {
+	c := json5{src: src, dst: make([]byte, 0, len(src)), mapped: true}
+	i, exp := c.space(0)
+	if exp == "" {
+		if i, exp = c.value(i); exp == "" {
+			if i, exp = c.space(i); exp == "" && i < len(src) {
+				exp = "end of json"
+			}
+		}
+	}
+	if exp != "" {
+		return Spec(src), &OffsetMap{src: src}
+	}
+	c.mark(len(src))
+	return c.dst, &OffsetMap{src: src, marks: c.marks}
+}
+
+// OffsetMap translates the offsets of the json that's returned by SpecMap to
+// the offsets in the original input. For example, to show where an error
+// from encoding/json is in the original file:
+//
+//	data, m := pretty.SpecMap(src)
+//	err := json.Unmarshal(data, &v)
+//	if serr, ok := err.(*json.SyntaxError); ok {
+//		line, column := m.Position(int(serr.Offset))
+//	}
+type OffsetMap struct {
+	src   []byte
+	marks []offsetMark
+}
+
+// offsetMark is where the bytes in dst start to come from src.
+type offsetMark struct {
+	dst, src int
+}
+
+// Offset returns the offset in the original input for the offset in the
+// json. An offset that's inside of a token that was changed, such as an
+// unquoted key, may be anywhere in that token.
+func (m *OffsetMap) Offset(offset int) int {
+	if len(m.marks) == 0 {
+		return offset
+	}
+	i := sort.Search(len(m.marks), func(i int) bool {
+		return m.marks[i].dst > offset
+	}) - 1
+	if i < 0 {
+		return m.marks[0].src
+	}
+	src := m.marks[i].src + offset - m.marks[i].dst
+	if i+1 < len(m.marks) && src >= m.marks[i+1].src {
+		// the token is longer than it was in the input
+		src = m.marks[i+1].src - 1
+	}
+	if src > len(m.src) {
+		src = len(m.src)
+	}
+	return src
+}
+
+// Position returns the line and byte column in the original input for the
+// offset in the json, starting at 1.
+func (m *OffsetMap) Position(offset int) (line, column int) {
+	return position(m.src, m.Offset(offset))
+}
@@ -0,0 +1,63 @@
+package pretty
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSpecMap(t *testing.T) {
+	src := `// config
+{
+  name: 'pretty', /* the name */
+  tags: ['a', "b",],
+  size: 0x10,
+}
+`
+	res, m := SpecMap([]byte(src))
+	expect := "\n{\n  \"name\": \"pretty\", \n  \"tags\": [\"a\", \"b\"],\n" +
+		"  \"size\": 16\n}\n"
+	assertEqual(t, expect, string(res))
+	tests := []struct {
+		token        string
+		line, column int
+	}{
+		{`{`, 2, 1},
+		{`"name"`, 3, 3},
+		{`"pretty"`, 3, 9},
+		{`"b"`, 4, 15},
+		{`]`, 4, 19},
+		{`16`, 5, 9},
+		{`}`, 6, 1},
+	}
+	for _, tt := range tests {
+		offset := bytes.Index(res, []byte(tt.token))
+		line, column := m.Position(offset)
+		if line != tt.line || column != tt.column {
+			t.Fatalf("%s: expected %d:%d, got %d:%d", tt.token, tt.line,
+				tt.column, line, column)
+		}
+	}
+	assertEqual(t, len(src), m.Offset(len(res)))
+}
+
+func TestSpecMapErrors(t *testing.T) {
+	// the NaN is converted, but it's not valid json
+	src := "{\n  // the values\n  a: 1, b: NaN\n}"
+	res, m := SpecMap([]byte(src))
+	var v interface{}
+	err := json.Unmarshal(res, &v)
+	serr, ok := err.(*json.SyntaxError)
+	if !ok {
+		t.Fatalf("expected *json.SyntaxError, got %v", err)
+	}
+	line, column := m.Position(int(serr.Offset) - 1)
+	assertEqual(t, 3, line)
+	assertEqual(t, 12, column)
+
+	// invalid input is the same as Spec
+	src = "{\"a\": 1 // one\n\"b\": 2}"
+	res, m = SpecMap([]byte(src))
+	assertEqual(t, string(Spec([]byte(src))), string(res))
+	assertEqual(t, 15, m.Offset(15))
+}
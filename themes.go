@@ -0,0 +1,199 @@
+package pretty
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// theme is the colors of a style, as 0xRRGGBB values.
+type theme struct {
+	key, str, num, lit, null, esc, brackets uint32
+	// bold makes the keys and brackets bold
+	bold bool
+	// bg is the background, which is only used by the stylesheets because
+	// terminals keep their own background
+	bg uint32
+}
+
+// themes are the built-in themes. Each one is registered with its name for
+// 24-bit color, and with a "-256" suffix for 256 colors.
+var themes = []struct {
+	name  string
+	theme theme
+}{
+	{"solarized-dark", theme{
+		key: 0x268bd2, str: 0x2aa198, num: 0xd33682, lit: 0xcb4b16,
+		null: 0x586e75, esc: 0x6c71c4, brackets: 0x93a1a1,
+		bg: 0x002b36,
+	}},
+	{"solarized-light", theme{
+		key: 0x268bd2, str: 0x2aa198, num: 0xd33682, lit: 0xcb4b16,
+		null: 0x93a1a1, esc: 0x6c71c4, brackets: 0x586e75,
+		bg: 0xfdf6e3,
+	}},
+	{"monokai", theme{
+		key: 0x66d9ef, str: 0xe6db74, num: 0xae81ff, lit: 0xfd971f,
+		null: 0x75715e, esc: 0xf92672, brackets: 0xf8f8f2,
+		bg: 0x272822,
+	}},
+	{"dracula", theme{
+		key: 0x8be9fd, str: 0xf1fa8c, num: 0xbd93f9, lit: 0xff79c6,
+		null: 0x6272a4, esc: 0xffb86c, brackets: 0xf8f8f2,
+		bg: 0x282a36,
+	}},
+	{"github-light", theme{
+		key: 0x0550ae, str: 0x0a3069, num: 0x953800, lit: 0xcf222e,
+		null: 0x6e7781, esc: 0x8250df, brackets: 0x24292f,
+		bg: 0xffffff,
+	}},
+	// the Okabe-Ito colors, which can be told apart with the common kinds
+	// of color blindness, for dark backgrounds
+	{"high-contrast", theme{
+		key: 0x56b4e9, str: 0x009e73, num: 0xe69f00, lit: 0xcc79a7,
+		null: 0xbbbbbb, esc: 0xf0e442, brackets: 0xffffff, bold: true,
+		bg: 0x000000,
+	}},
+}
+
+// style returns the theme as a Style, using 24-bit color, or the nearest of
+// the 256 colors.
+func (t theme) style(ansi256 bool) *Style {
+	color := func(rgb uint32, bold bool) [2]string {
+		var seq []byte
+		if bold {
+			seq = append(seq, "\x1B[1m"...)
+		}
+		seq = appendANSIColor(seq, rgb, ansi256)
+		return [2]string{string(seq), "\x1B[0m"}
+	}
+	return &Style{
+		Key:      color(t.key, t.bold),
+		String:   color(t.str, false),
+		Number:   color(t.num, false),
+		True:     color(t.lit, false),
+		False:    color(t.lit, false),
+		Null:     color(t.null, false),
+		Escape:   color(t.esc, false),
+		Brackets: color(t.brackets, t.bold),
+		Match:    [2]string{"\x1B[7m" + color(t.key, false)[0], "\x1B[0m"},
+		Append:   appendTerminalByte,
+	}
+}
+
+// appendANSIColor appends the escape sequence for the foreground color.
+func appendANSIColor(dst []byte, rgb uint32, ansi256 bool) []byte {
+	if ansi256 {
+		dst = append(dst, "\x1B[38;5;"...)
+		dst = strconv.AppendInt(dst, int64(rgbTo256(rgb)), 10)
+		return append(dst, 'm')
+	}
+	dst = append(dst, "\x1B[38;2;"...)
+	dst = strconv.AppendInt(dst, int64(rgb>>16&0xFF), 10)
+	dst = append(dst, ';')
+	dst = strconv.AppendInt(dst, int64(rgb>>8&0xFF), 10)
+	dst = append(dst, ';')
+	dst = strconv.AppendInt(dst, int64(rgb&0xFF), 10)
+	return append(dst, 'm')
+}
+
+// cubeLevels are the values of each channel in the 6x6x6 color cube of the
+// 256 colors, which starts at 16.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// rgbTo256 returns the nearest of the 256 colors, from the color cube or
+// the grayscale ramp. The first 16 colors are not used because terminals
+// change them.
+func rgbTo256(rgb uint32) int {
+	r, g, b := int(rgb>>16&0xFF), int(rgb>>8&0xFF), int(rgb&0xFF)
+	nearest := func(v int) int {
+		best := 0
+		for i, level := range cubeLevels {
+			if abs(v-level) < abs(v-cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := nearest(r), nearest(g), nearest(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := dist3(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+	// the grayscale ramp is 232 to 255, for the values 8 to 238
+	gi = ((r+g+b)/3 - 8 + 5) / 10
+	if gi < 0 {
+		gi = 0
+	} else if gi > 23 {
+		gi = 23
+	}
+	gray := 8 + 10*gi
+	if dist3(r, g, b, gray, gray, gray) < cubeDist {
+		return 232 + gi
+	}
+	return cube
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func dist3(r1, g1, b1, r2, g2, b2 int) int {
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
+
+var registry struct {
+	once   sync.Once
+	mu     sync.RWMutex
+	styles map[string]*Style
+}
+
+// loadStyles adds the built-in styles to the registry.
+func loadStyles() {
+	registry.styles = map[string]*Style{"terminal": TerminalStyle,
+		"terminal-rainbow": TerminalRainbowStyle}
+	for _, t := range themes {
+		registry.styles[t.name] = t.theme.style(false)
+		registry.styles[t.name+"-256"] = t.theme.style(true)
+	}
+}
+
+// StyleByName returns the style that's registered with the name. The
+// built-in styles are "terminal", which is TerminalStyle,
+// "terminal-rainbow", which is TerminalRainbowStyle, and the themes
+// "solarized-dark", "solarized-light", "monokai", "dracula", "github-light",
+// and "high-contrast". The themes use 24-bit color, and there's a 256 color
+// variant of each one that has a "-256" suffix, such as "dracula-256".
+//
+// The returned style is shared and should not be modified.
+func StyleByName(name string) (*Style, bool) {
+	registry.once.Do(loadStyles)
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	style, ok := registry.styles[name]
+	return style, ok
+}
+
+// RegisterStyle adds the style to the registry, so that it's returned by
+// StyleByName. It replaces any style that's already registered with the
+// name.
+func RegisterStyle(name string, style *Style) {
+	registry.once.Do(loadStyles)
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.styles[name] = style
+}
+
+// StyleNames returns the sorted names of the registered styles.
+func StyleNames() []string {
+	registry.once.Do(loadStyles)
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	names := make([]string, 0, len(registry.styles))
+	for name := range registry.styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
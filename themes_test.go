@@ -0,0 +1,71 @@
+package pretty
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStyleByName(t *testing.T) {
+	names := []string{"terminal", "solarized-dark", "solarized-light",
+		"monokai", "dracula", "github-light", "high-contrast"}
+	for _, name := range names {
+		for _, suffix := range []string{"", "-256"} {
+			if name == "terminal" && suffix != "" {
+				continue
+			}
+			style, ok := StyleByName(name + suffix)
+			if !ok {
+				t.Fatalf("%s: expected a style", name+suffix)
+			}
+			res := string(Color([]byte(`{"a":[1,true,null,"\n"]}`), style))
+			if !strings.Contains(res, "\x1B[") {
+				t.Fatalf("%s: expected color, got %q", name+suffix, res)
+			}
+		}
+	}
+	style, _ := StyleByName("terminal")
+	assertEqual(t, TerminalStyle, style)
+	style, _ = StyleByName("terminal-rainbow")
+	assertEqual(t, TerminalRainbowStyle, style)
+	_, ok := StyleByName("solarized")
+	assertEqual(t, false, ok)
+
+	style, _ = StyleByName("dracula")
+	assertEqual(t, [2]string{"\x1B[38;2;139;233;253m", "\x1B[0m"}, style.Key)
+	style, _ = StyleByName("dracula-256")
+	assertEqual(t, [2]string{"\x1B[38;5;117m", "\x1B[0m"}, style.Key)
+	style, _ = StyleByName("high-contrast")
+	assertEqual(t, [2]string{"\x1B[1m\x1B[38;2;86;180;233m", "\x1B[0m"},
+		style.Key)
+}
+
+func TestRegisterStyle(t *testing.T) {
+	plain := &Style{Key: [2]string{"<", ">"}}
+	RegisterStyle("test-plain", plain)
+	style, ok := StyleByName("test-plain")
+	assertEqual(t, true, ok)
+	assertEqual(t, plain, style)
+	var found bool
+	for _, name := range StyleNames() {
+		found = found || name == "test-plain"
+	}
+	assertEqual(t, true, found)
+}
+
+func TestRGBTo256(t *testing.T) {
+	tests := []struct {
+		rgb    uint32
+		expect int
+	}{
+		{0x000000, 16},
+		{0xffffff, 231},
+		{0xff0000, 196},
+		{0x5f87af, 67},
+		{0x808080, 244},
+		{0x121212, 233},
+		{0x8be9fd, 117},
+	}
+	for _, tt := range tests {
+		assertEqual(t, tt.expect, rgbTo256(tt.rgb))
+	}
+}
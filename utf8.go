@@ -0,0 +1,84 @@
+package pretty
+
+import "unicode/utf8"
+
+// UTF8Policy is what to do with strings that have invalid UTF-8 bytes, or
+// escapes of lone surrogates, such as "\ud800"
+type UTF8Policy int
+
+const (
+	// UTF8Pass keeps the strings as they are in the input
+	UTF8Pass UTF8Policy = iota
+	// UTF8Replace replaces each invalid byte, and each lone surrogate
+	// escape, with the U+FFFD replacement character
+	UTF8Replace
+	// UTF8Escape replaces each invalid byte, and each lone surrogate escape,
+	// with the \ufffd escape
+	UTF8Escape
+	// UTF8Error causes PrettyE, UglyE, and ColorE to fail with a
+	// *SyntaxError on lone surrogate escapes, as they do on invalid bytes.
+	// The functions that don't return an error keep the strings as they are.
+	UTF8Error
+)
+
+// appendFixedString appends the json string, replacing the invalid UTF-8
+// bytes and lone surrogate escapes using the policy.
+func appendFixedString(buf, str []byte, policy UTF8Policy) []byte {
+	repl := "\ufffd"
+	if policy == UTF8Escape {
+		repl = `\ufffd`
+	}
+	for {
+		start, end := nextInvalid(str, 0)
+		if start == -1 {
+			return append(buf, str...)
+		}
+		buf = append(buf, str[:start]...)
+		buf = append(buf, repl...)
+		str = str[end:]
+	}
+}
+
+// nextInvalid returns the span of the next invalid UTF-8 byte, or lone
+// surrogate escape, in the json string, starting at i. It returns -1 when
+// there isn't one.
+func nextInvalid(str []byte, i int) (int, int) {
+	for i < len(str) {
+		c := str[i]
+		if c == '\\' {
+			if !isUnicodeEscape(str, i) {
+				i += 2
+				continue
+			}
+			r := hexRune(str[i+2 : i+6])
+			if r >= 0xD800 && r <= 0xDBFF && isUnicodeEscape(str, i+6) {
+				if r2 := hexRune(str[i+8 : i+12]); r2 >= 0xDC00 && r2 <= 0xDFFF {
+					i += 12
+					continue
+				}
+			}
+			if r >= 0xD800 && r <= 0xDFFF {
+				return i, i + 6
+			}
+			i += 6
+			continue
+		}
+		if c < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, n := utf8.DecodeRune(str[i:])
+		if r == utf8.RuneError && n == 1 {
+			return i, i + 1
+		}
+		i += n
+	}
+	return -1, -1
+}
+
+// isUnicodeEscape returns true if there's a \uXXXX escape at i.
+func isUnicodeEscape(str []byte, i int) bool {
+	return len(str)-i >= 6 && str[i] == '\\' && str[i+1] == 'u' &&
+		isHex(str[i+2]) && isHex(str[i+3]) && isHex(str[i+4]) &&
+		isHex(str[i+5])
+}
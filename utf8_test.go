@@ -0,0 +1,65 @@
+package pretty
+
+import "testing"
+
+func TestInvalidUTF8(t *testing.T) {
+	json := "[\"a\xffb\",\"\\ud800x\",\"\\ud83d\\ude00\",\"\\\\ud800\",\"\\udc00\\ud800\"]"
+	tests := []struct {
+		policy UTF8Policy
+		expect string
+	}{
+		{UTF8Pass, json},
+		{UTF8Error, json},
+		{UTF8Replace, "[\"a\ufffdb\",\"\ufffdx\",\"\\ud83d\\ude00\",\"\\\\ud800\"," +
+			"\"\ufffd\ufffd\"]"},
+		{UTF8Escape, `["a\ufffdb","\ufffdx","\ud83d\ude00","\\ud800",` +
+			`"\ufffd\ufffd"]`},
+	}
+	for _, tt := range tests {
+		opts := Options{InvalidUTF8: tt.policy}
+		res := string(UglyOptions([]byte(json), &opts))
+		assertEqual(t, tt.expect, res)
+		copts := ColorOptions{Style: &Style{}, InvalidUTF8: tt.policy}
+		assertEqual(t, tt.expect, string(ColorWithOptions([]byte(json), &copts)))
+	}
+
+	// the escapes are decoded after the replacement
+	opts := Options{InvalidUTF8: UTF8Escape, Escapes: EscapeMinimal}
+	res := string(UglyOptions([]byte("{\"\xc3\":\"\\udfff\"}"), &opts))
+	assertEqual(t, "{\"\ufffd\":\"\ufffd\"}", res)
+}
+
+func TestInvalidUTF8Errors(t *testing.T) {
+	_, err := PrettyE([]byte("[\"a\xffb\"]"), nil)
+	assertEqual(t, "pretty: invalid byte 0xff at line 1, column 4, "+
+		"expected valid UTF-8", err.Error())
+	_, err = PrettyE([]byte("[\"a\xffb\"]"), &Options{InvalidUTF8: UTF8Replace})
+	assertEqual(t, nil, err)
+
+	// lone surrogates are only reported with UTF8Error
+	_, err = PrettyE([]byte(`["a\ud800"]`), nil)
+	assertEqual(t, nil, err)
+	_, err = PrettyE([]byte(`["a\ud800"]`), &Options{InvalidUTF8: UTF8Error})
+	assertEqual(t, "pretty: lone surrogate \\ud800 at line 1, column 4, "+
+		"expected surrogate pair", err.Error())
+	_, err = UglyE([]byte(`["a\ud800"]`), &Options{InvalidUTF8: UTF8Error})
+	assertEqual(t, "pretty: lone surrogate \\ud800 at line 1, column 4, "+
+		"expected surrogate pair", err.Error())
+	res, err := UglyE([]byte("[ \"a\xffb\"]"), &Options{InvalidUTF8: UTF8Escape})
+	assertEqual(t, nil, err)
+	assertEqual(t, `["a\ufffdb"]`, string(res))
+}
+
+func TestInvalidUTF8Color(t *testing.T) {
+	// the replacements are colored, and highlighted, as any other string
+	opts := ColorOptions{Style: TerminalStyle, InvalidUTF8: UTF8Replace}
+	json := []byte("{\"k\xff\":\"v\\udc00\"}")
+	res := ColorWithOptions(json, &opts)
+	expect := Color([]byte("{\"k\ufffd\":\"v\ufffd\"}"), TerminalStyle)
+	assertEqual(t, string(expect), string(res))
+	opts.Highlight = &Highlight{Substring: "v\ufffd"}
+	res = ColorWithOptions(json, &opts)
+	expect = ColorWithOptions([]byte("{\"k\ufffd\":\"v\ufffd\"}"),
+		&ColorOptions{Style: TerminalStyle, Highlight: opts.Highlight})
+	assertEqual(t, string(expect), string(res))
+}
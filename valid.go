@@ -0,0 +1,435 @@
+package pretty
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateOptions is Valid options
+type ValidateOptions struct {
+	// AllowInvalidUTF8 will accept strings that are not valid UTF-8
+	// Default is false
+	AllowInvalidUTF8 bool
+	// AllowNaN will accept the NaN and Inf numbers that Pretty passes through,
+	// such as NaN, -Inf and +Infinity
+	// Default is false
+	AllowNaN bool
+	// MaxDepth is the maximum nesting of arrays and objects
+	// Default is zero, which is no limit
+	MaxDepth int
+	// DisallowDuplicateKeys will reject objects that have the same key more
+	// than once
+	// Default is false
+	DisallowDuplicateKeys bool
+}
+
+// Valid returns true if the input is valid json per the official spec:
+// https://tools.ietf.org/html/rfc8259
+func Valid(json []byte) bool {
+	v := validator{}
+	return v.validate(json) == nil
+}
+
+// ValidOptions is like Valid but with customized options. It returns a
+// *SyntaxError when the input is not valid.
+func ValidOptions(json []byte, opts *ValidateOptions) error {
+	v := validator{}
+	if opts != nil {
+		v.ValidateOptions = *opts
+	}
+	return v.validate(json)
+}
+
+// SyntaxError is a description of a json syntax error, such as is returned
+// by PrettyE.
+type SyntaxError struct {
+	// Offset is the byte offset in the input where the error occurred
+	Offset int
+	// Line is the line of the error, starting at 1
+	Line int
+	// Column is the byte column of the error, starting at 1
+	Column int
+	// Expected is a description of what was expected at the Offset
+	Expected string
+	found    string
+}
+
+func newSyntaxError(data []byte, offset int, expected string) *SyntaxError {
+	err := &SyntaxError{Offset: offset, Expected: expected}
+	err.Line, err.Column = position(data, offset)
+	if offset == len(data) {
+		err.found = "unexpected end of json"
+	} else if data[offset] < 0x80 {
+		err.found = "invalid character " + strconv.QuoteRune(rune(data[offset]))
+	} else {
+		err.found = "invalid byte 0x" + strconv.FormatUint(uint64(data[offset]), 16)
+	}
+	return err
+}
+
+// position returns the line and byte column of the offset, starting at 1.
+func position(data []byte, offset int) (line, column int) {
+	line, column = 1, 1
+	for i := 0; i < offset && i < len(data); i++ {
+		if data[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
+
+func (err *SyntaxError) Error() string {
+	return "pretty: " + err.found + " at line " + strconv.Itoa(err.Line) +
+		", column " + strconv.Itoa(err.Column) + ", expected " + err.Expected
+}
+
+type validator struct {
+	ValidateOptions
+	// ijson rejects the numbers that are not allowed by I-JSON,
+	// https://tools.ietf.org/html/rfc7493
+	ijson bool
+	// surrogates rejects the escapes of lone surrogates
+	surrogates bool
+	depth      int
+	keys       []map[string]bool
+}
+
+const (
+	expUniqueKey     = "unique key"
+	expFiniteNumber  = "number in the range of a double"
+	expSurrogatePair = "surrogate pair"
+)
+
+// validate returns a *SyntaxError when data is not a single valid json value
+// per https://tools.ietf.org/html/rfc8259.
+func (v *validator) validate(data []byte) error {
+	i, exp := v.value(data, skipws(data, 0))
+	if exp == "" {
+		if i = skipws(data, i); i < len(data) {
+			exp = "end of json"
+		}
+	}
+	if exp != "" {
+		err := newSyntaxError(data, i, exp)
+		switch exp {
+		case expUniqueKey:
+			err.found = "duplicate key " +
+				strconv.Quote(string(parsestr(data[i:])))
+		case expFiniteNumber:
+			j, _ := validNumber(data, i)
+			err.found = "number " + string(data[i:j]) + " out of range"
+		case expSurrogatePair:
+			err.found = "lone surrogate " + string(data[i:i+6])
+		}
+		return err
+	}
+	return nil
+}
+
+func skipws(data []byte, i int) int {
+	for ; i < len(data); i++ {
+		if c := data[i]; c > ' ' ||
+			(c != ' ' && c != '\t' && c != '\n' && c != '\r') {
+			return i
+		}
+	}
+	return i
+}
+
+// The validator functions return the index following the value, or the
+// index of the error along with a description of what was expected.
+
+func (v *validator) value(data []byte, i int) (int, string) {
+	if i < len(data) {
+		if v.AllowNaN {
+			if j := validNaN(data, i); j > i {
+				return j, ""
+			}
+		}
+		switch data[i] {
+		case '{':
+			return v.object(data, i+1)
+		case '[':
+			return v.array(data, i+1)
+		case '"':
+			return v.string(data, i+1)
+		case 't':
+			return validLiteral(data, i, "true")
+		case 'f':
+			return validLiteral(data, i, "false")
+		case 'n':
+			return validLiteral(data, i, "null")
+		case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			j, exp := validNumber(data, i)
+			if v.ijson && exp == "" {
+				if _, err := strconv.ParseFloat(string(data[i:j]), 64); err != nil {
+					return i, expFiniteNumber
+				}
+			}
+			return j, exp
+		}
+	}
+	return i, "value"
+}
+
+func (v *validator) enter(i int) (int, string) {
+	v.depth++
+	if v.MaxDepth > 0 && v.depth > v.MaxDepth {
+		return i - 1, "at most " + strconv.Itoa(v.MaxDepth) +
+			" levels of nesting"
+	}
+	return i, ""
+}
+
+func (v *validator) object(data []byte, i int) (int, string) {
+	var exp string
+	if i, exp = v.enter(i); exp != "" {
+		return i, exp
+	}
+	i = skipws(data, i)
+	if i < len(data) && data[i] == '}' {
+		v.depth--
+		return i + 1, ""
+	}
+	var keys map[string]bool
+	if v.DisallowDuplicateKeys {
+		// reuse the map from a previous object at the same depth
+		for len(v.keys) < v.depth {
+			v.keys = append(v.keys, nil)
+		}
+		if keys = v.keys[v.depth-1]; keys == nil {
+			keys = make(map[string]bool)
+			v.keys[v.depth-1] = keys
+		}
+		for key := range keys {
+			delete(keys, key)
+		}
+	}
+	for {
+		if i == len(data) || data[i] != '"' {
+			return i, "string"
+		}
+		s := i
+		if i, exp = v.string(data, i+1); exp != "" {
+			return i, exp
+		}
+		if keys != nil {
+			key := string(parsestr(data[s:i]))
+			if keys[key] {
+				return s, expUniqueKey
+			}
+			keys[key] = true
+		}
+		if i = skipws(data, i); i == len(data) || data[i] != ':' {
+			return i, "':'"
+		}
+		if i, exp = v.value(data, skipws(data, i+1)); exp != "" {
+			return i, exp
+		}
+		if i = skipws(data, i); i < len(data) {
+			if data[i] == '}' {
+				v.depth--
+				return i + 1, ""
+			}
+			if data[i] == ',' {
+				i = skipws(data, i+1)
+				continue
+			}
+		}
+		return i, "',' or '}'"
+	}
+}
+
+func (v *validator) array(data []byte, i int) (int, string) {
+	var exp string
+	if i, exp = v.enter(i); exp != "" {
+		return i, exp
+	}
+	i = skipws(data, i)
+	if i < len(data) && data[i] == ']' {
+		v.depth--
+		return i + 1, ""
+	}
+	for {
+		if i, exp = v.value(data, i); exp != "" {
+			return i, exp
+		}
+		if i = skipws(data, i); i < len(data) {
+			if data[i] == ']' {
+				v.depth--
+				return i + 1, ""
+			}
+			if data[i] == ',' {
+				i = skipws(data, i+1)
+				continue
+			}
+		}
+		return i, "',' or ']'"
+	}
+}
+
+func (v *validator) string(data []byte, i int) (int, string) {
+	for ; i < len(data); i++ {
+		c := data[i]
+		if strSafe[c] {
+			continue
+		}
+		if c == '"' {
+			return i + 1, ""
+		}
+		if c < ' ' {
+			return i, "escaped control character"
+		}
+		if c >= utf8.RuneSelf {
+			if !v.AllowInvalidUTF8 {
+				r, n := utf8.DecodeRune(data[i:])
+				if r == utf8.RuneError && n == 1 {
+					return i, "valid UTF-8"
+				}
+				i += n - 1
+			}
+			continue
+		}
+		if c == '\\' {
+			if i++; i == len(data) {
+				break
+			}
+			switch data[i] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			case 'u':
+				for j := 0; j < 4; j++ {
+					if i++; i == len(data) || !isHex(data[i]) {
+						return i, "hex digit"
+					}
+				}
+				if v.surrogates {
+					var exp string
+					if i, exp = validSurrogate(data, i); exp != "" {
+						return i, exp
+					}
+				}
+			default:
+				return i, "escape character"
+			}
+		}
+	}
+	return i, "'\"'"
+}
+
+// strSafe are the string bytes that don't need to be checked any further
+var strSafe = func() (safe [256]bool) {
+	for c := ' '; c < utf8.RuneSelf; c++ {
+		safe[c] = c != '"' && c != '\\'
+	}
+	return safe
+}()
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') ||
+		(c >= 'A' && c <= 'F')
+}
+
+// validSurrogate checks that the \u escape that ends at i is not a lone
+// surrogate. It returns the index of the last hex digit of the escape, or
+// of the escape that follows it when it's a surrogate pair.
+func validSurrogate(data []byte, i int) (int, string) {
+	r := hexRune(data[i-3 : i+1])
+	if r >= 0xDC00 && r <= 0xDFFF {
+		return i - 5, expSurrogatePair
+	}
+	if r < 0xD800 || r > 0xDBFF {
+		return i, ""
+	}
+	if len(data)-i < 7 || data[i+1] != '\\' || data[i+2] != 'u' ||
+		!isHex(data[i+3]) || !isHex(data[i+4]) || !isHex(data[i+5]) ||
+		!isHex(data[i+6]) {
+		return i - 5, expSurrogatePair
+	}
+	if r = hexRune(data[i+3 : i+7]); r < 0xDC00 || r > 0xDFFF {
+		return i - 5, expSurrogatePair
+	}
+	return i + 6, ""
+}
+
+// hexRune returns the value of the hex digits.
+func hexRune(hex []byte) rune {
+	var r rune
+	for _, c := range hex {
+		switch {
+		case c >= 'a':
+			c -= 'a' - 10
+		case c >= 'A':
+			c -= 'A' - 10
+		default:
+			c -= '0'
+		}
+		r = r<<4 | rune(c)
+	}
+	return r
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func validNumber(data []byte, i int) (int, string) {
+	if data[i] == '-' {
+		i++
+	}
+	if i == len(data) || !isDigit(data[i]) {
+		return i, "digit"
+	}
+	if data[i] == '0' {
+		i++
+	} else {
+		for i++; i < len(data) && isDigit(data[i]); i++ {
+		}
+	}
+	if i < len(data) && data[i] == '.' {
+		if i++; i == len(data) || !isDigit(data[i]) {
+			return i, "digit"
+		}
+		for i++; i < len(data) && isDigit(data[i]); i++ {
+		}
+	}
+	if i < len(data) && (data[i] == 'e' || data[i] == 'E') {
+		if i++; i < len(data) && (data[i] == '+' || data[i] == '-') {
+			i++
+		}
+		if i == len(data) || !isDigit(data[i]) {
+			return i, "digit"
+		}
+		for i++; i < len(data) && isDigit(data[i]); i++ {
+		}
+	}
+	return i, ""
+}
+
+// validNaN returns the index following a NaN or Inf number, or i if there
+// isn't one.
+func validNaN(data []byte, i int) int {
+	j := i
+	if data[j] == '+' || data[j] == '-' {
+		j++
+	}
+	for _, lit := range []string{"nan", "infinity", "inf"} {
+		if len(data)-j >= len(lit) &&
+			strings.EqualFold(string(data[j:j+len(lit)]), lit) {
+			return j + len(lit)
+		}
+	}
+	return i
+}
+
+func validLiteral(data []byte, i int, lit string) (int, string) {
+	for j := 0; j < len(lit); j, i = j+1, i+1 {
+		if i == len(data) || data[i] != lit[j] {
+			return i, "'" + lit + "'"
+		}
+	}
+	return i, ""
+}
@@ -0,0 +1,168 @@
+package pretty
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPrettyE(t *testing.T) {
+	out, err := PrettyE(example1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(Pretty(example1)), string(out))
+	out, err = UglyE(example1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(Ugly(example1)), string(out))
+	out, err = ColorE(example1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, string(Color(example1, nil)), string(out))
+}
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		json     string
+		offset   int
+		line     int
+		column   int
+		expected string
+	}{
+		{``, 0, 1, 1, "value"},
+		{`{"a":1,}`, 7, 1, 8, "string"},
+		{"{\n  \"a\": 1\n  \"b\": 2\n}", 13, 3, 3, "',' or '}'"},
+		{`[1,2`, 4, 1, 5, "',' or ']'"},
+		{`{"a" 1}`, 5, 1, 6, "':'"},
+		{`"abc`, 4, 1, 5, "'\"'"},
+		{"\"a\tb\"", 2, 1, 3, "escaped control character"},
+		{`"\x"`, 2, 1, 3, "escape character"},
+		{`"\u12g4"`, 5, 1, 6, "hex digit"},
+		{`-`, 1, 1, 2, "digit"},
+		{`1.`, 2, 1, 3, "digit"},
+		{`1e+`, 3, 1, 4, "digit"},
+		{`01`, 1, 1, 2, "end of json"},
+		{`tru`, 3, 1, 4, "'true'"},
+		{`nul1`, 3, 1, 4, "'null'"},
+		{`{"num":NaN}`, 7, 1, 8, "value"},
+		{`[1] [2]`, 4, 1, 5, "end of json"},
+	}
+	for _, tt := range tests {
+		_, err := PrettyE([]byte(tt.json), nil)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Fatalf("%s: expected *SyntaxError, got %v", tt.json, err)
+		}
+		assertEqual(t, tt.offset, serr.Offset)
+		assertEqual(t, tt.line, serr.Line)
+		assertEqual(t, tt.column, serr.Column)
+		assertEqual(t, tt.expected, serr.Expected)
+		if json.Valid([]byte(tt.json)) {
+			t.Fatalf("%s: expected invalid json", tt.json)
+		}
+	}
+	_, err := UglyE([]byte(`{"a":1,}`), nil)
+	assertEqual(t, "pretty: invalid character '}' at line 1, column 8, "+
+		"expected string", err.Error())
+	_, err = ColorE([]byte(`[1,2`), nil)
+	assertEqual(t, "pretty: unexpected end of json at line 1, column 5, "+
+		"expected ',' or ']'", err.Error())
+}
+
+func TestColorE(t *testing.T) {
+	// ColorE has the same policies as PrettyE and UglyE
+	json := []byte(`{"a":"\ud800","a":2}`)
+	out, err := ColorE(json, nil)
+	assertEqual(t, nil, err)
+	assertEqual(t, string(Color(json, nil)), string(out))
+	_, err = ColorE(json, &ColorOptions{DuplicateKeys: DuplicateError})
+	assertEqual(t, "pretty: duplicate key \"a\" at line 1, column 15, "+
+		"expected unique key", err.Error())
+	_, err = ColorE(json, &ColorOptions{InvalidUTF8: UTF8Error})
+	assertEqual(t, "pretty: lone surrogate \\ud800 at line 1, column 7, "+
+		"expected surrogate pair", err.Error())
+	_, err = ColorE([]byte("[\"\xff\"]"), nil)
+	assertEqual(t, "pretty: invalid byte 0xff at line 1, column 3, "+
+		"expected valid UTF-8", err.Error())
+	out, err = ColorE([]byte("[\"\xff\"]"),
+		&ColorOptions{Style: &Style{}, InvalidUTF8: UTF8Escape})
+	assertEqual(t, nil, err)
+	assertEqual(t, `["\ufffd"]`, string(out))
+}
+
+func TestValid(t *testing.T) {
+	valid := []string{
+		`{}`, `[]`, `""`, `0`, `-0`, `-1.5e+10`, `1E-2`, `true`, `false`, `null`,
+		` {"a" : [1, {"b": null}], "c": "é\n\"\\\/"} `,
+		`"héllo wörld 😀"`,
+		string(example1),
+		example2,
+	}
+	invalid := []string{
+		``, ` `, `{`, `[1,]`, `{"a":1,}`, `01`, `1.`, `.1`, `+1`, `1e`, `--1`,
+		`"\a"`, `"\u00"`, "\"\x01\"", "\"\xff\"", "\"\xc3\"", `[1] 2`,
+		`NaN`, `{"a"}`, `{1:2}`, `tru`, `nulll`, string(example3),
+	}
+	for _, js := range valid {
+		if !Valid([]byte(js)) {
+			t.Fatalf("expected valid: %s", js)
+		}
+	}
+	for _, js := range invalid {
+		if Valid([]byte(js)) {
+			t.Fatalf("expected invalid: %s", js)
+		}
+	}
+}
+
+func TestValidOptions(t *testing.T) {
+	err := ValidOptions([]byte("\"\xff\""), nil)
+	assertEqual(t, "valid UTF-8", err.(*SyntaxError).Expected)
+	err = ValidOptions([]byte("\"\xff\""),
+		&ValidateOptions{AllowInvalidUTF8: true})
+	assertEqual(t, nil, err)
+	for _, val := range []string{"NaN", "nan", "inf", "-Inf", "+Infinity"} {
+		err = ValidOptions([]byte(`{"num":`+val+`}`),
+			&ValidateOptions{AllowNaN: true})
+		assertEqual(t, nil, err)
+	}
+	err = ValidOptions([]byte(`[[1],[[2]]]`), &ValidateOptions{MaxDepth: 2})
+	serr := err.(*SyntaxError)
+	assertEqual(t, 6, serr.Offset)
+	assertEqual(t, "at most 2 levels of nesting", serr.Expected)
+	err = ValidOptions([]byte(`[[1],[2]]`), &ValidateOptions{MaxDepth: 2})
+	assertEqual(t, nil, err)
+}
+
+func TestValidRandom(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+	b := make([]byte, 32)
+	for i := 0; i < 100000; i++ {
+		for j := range b {
+			b[j] = "{}[],:\"\\ 1.-eatrun"[rand.Intn(18)]
+		}
+		if Valid(b) != json.Valid(b) {
+			t.Fatalf("mismatch for %s", b)
+		}
+	}
+}
+
+func BenchmarkValid(t *testing.B) {
+	t.ReportAllocs()
+	t.ResetTimer()
+	for i := 0; i < t.N; i++ {
+		Valid(example1)
+	}
+}
+
+func BenchmarkJSONValid(t *testing.B) {
+	t.ReportAllocs()
+	t.ResetTimer()
+	for i := 0; i < t.N; i++ {
+		json.Valid(example1)
+	}
+}